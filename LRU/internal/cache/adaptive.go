@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// SplitStats 老年区和青年区当前的容量划分，以及幽灵链表的命中情况
+type SplitStats struct {
+	OldCapacity, YoungCapacity int
+	MinOld, MaxOld             int  // 老年区容量的调整范围
+	Adaptive                   bool // 是否按幽灵链表的命中调整划分
+
+	OldGhosts, YoungGhosts       int   // 幽灵链表中 key 的数量
+	OldGhostHits, YoungGhostHits int64 // 未命中的 key 出现在幽灵链表中的次数
+}
+
+// adaptiveSplit 自适应划分的状态。被淘汰出缓存的 key 按来源记录在两个幽灵链表中：
+// 从老年区降级后没有再被访问就被淘汰的 key 记在 oldGhosts，说明老年区太小；
+// 其他（加载或预读后直接进入青年区的）key 记在 youngGhosts，说明青年区太小
+type adaptiveSplit struct {
+	mu                     sync.Mutex
+	on                     bool
+	minOld, maxOld         int
+	oldGhosts, youngGhosts ghostList
+	oldHits, youngHits     int64
+}
+
+// SetAdaptiveSplit 打开自适应划分：两个区的总容量不变，未命中的 key 出现在某个区的幽灵链表中时，
+// 仿照 ARC 把容量向这个区移动，老年区的容量保持在 [minOld, maxOld] 之间。
+// minOld 小于 1 时为 1，maxOld 小于等于 0 或者超过总容量减 1 时为总容量减 1。
+// 只对两区策略有效，其他策略下不做任何事
+func (l *LRUCache) SetAdaptiveSplit(minOld, maxOld int) {
+	if l.twoZone == nil {
+		return
+	}
+	total := l.Capacity()
+	if minOld < 1 {
+		minOld = 1
+	}
+	if maxOld <= 0 || maxOld > total-1 {
+		maxOld = total - 1
+	}
+	if minOld > maxOld {
+		minOld = maxOld
+	}
+	a := &l.split
+	a.mu.Lock()
+	a.on = true
+	a.minOld, a.maxOld = minOld, maxOld
+	a.oldGhosts.init(total)
+	a.youngGhosts.init(total)
+	a.mu.Unlock()
+
+	// 当前的划分可能不在范围内
+	l.resize(0, minOld, maxOld)
+	l.flushEvicted()
+}
+
+// SplitStats 返回当前的容量划分，两区策略以外的策略返回零值
+func (l *LRUCache) SplitStats() SplitStats {
+	if l.twoZone == nil {
+		return SplitStats{}
+	}
+	a := &l.split
+	a.mu.Lock()
+	s := SplitStats{
+		MinOld:         a.minOld,
+		MaxOld:         a.maxOld,
+		Adaptive:       a.on,
+		OldGhosts:      a.oldGhosts.len(),
+		YoungGhosts:    a.youngGhosts.len(),
+		OldGhostHits:   a.oldHits,
+		YoungGhostHits: a.youngHits,
+	}
+	a.mu.Unlock()
+	s.OldCapacity = l.ZoneCapacity(ZoneOld)
+	s.YoungCapacity = l.ZoneCapacity(ZoneYoung)
+	return s
+}
+
+// addGhost 记录被淘汰出缓存的项，调用方持有 mu
+func (l *LRUCache) addGhost(item *ItemCache) {
+	a := &l.split
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.on {
+		return
+	}
+	if item.demoted {
+		a.youngGhosts.remove(item.Key)
+		a.oldGhosts.add(item.Key)
+	} else {
+		a.oldGhosts.remove(item.Key)
+		a.youngGhosts.add(item.Key)
+	}
+}
+
+// ghostHit 未命中时调用，key 在幽灵链表中时把容量向淘汰它的区移动，
+// 每次移动的数量为另一个幽灵链表和这个幽灵链表长度之比（至少为 1）
+func (l *LRUCache) ghostHit(key interface{}) {
+	a := &l.split
+	a.mu.Lock()
+	if !a.on {
+		a.mu.Unlock()
+		return
+	}
+	delta := 0
+	switch {
+	case a.oldGhosts.contains(key):
+		delta = max(a.youngGhosts.len()/a.oldGhosts.len(), 1)
+		a.oldGhosts.remove(key)
+		a.oldHits++
+	case a.youngGhosts.contains(key):
+		delta = -max(a.oldGhosts.len()/a.youngGhosts.len(), 1)
+		a.youngGhosts.remove(key)
+		a.youngHits++
+	}
+	minOld, maxOld := a.minOld, a.maxOld
+	a.mu.Unlock()
+	if delta == 0 {
+		return
+	}
+	l.resize(delta, minOld, maxOld)
+}
+
+// resize 把老年区的容量增加 delta 并限制在 [minOld, maxOld] 之间，青年区的容量相应变化，总容量不变。
+// 老年区缩小时多出来的项降级到已经扩大的青年区，不会被淘汰；老年区扩大时青年区淘汰多出来的项
+func (l *LRUCache) resize(delta, minOld, maxOld int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := min(max(l.twoZone.oldCapacity+delta, minOld), maxOld)
+	if old == l.twoZone.oldCapacity {
+		return
+	}
+	l.twoZone.resize(old)
+	l.evictLocked()
+}
+
+// ghostList 只记录 key 的有界链表，超过容量时丢弃最旧的 key
+type ghostList struct {
+	capacity int
+	list     *list.List // 队首是最新的，元素的值为 key
+	items    map[interface{}]*list.Element
+}
+
+func (g *ghostList) init(capacity int) {
+	g.capacity = capacity
+	g.list = list.New()
+	g.items = make(map[interface{}]*list.Element)
+}
+
+func (g *ghostList) add(key interface{}) {
+	if e, ok := g.items[key]; ok {
+		g.list.MoveToFront(e)
+		return
+	}
+	g.items[key] = g.list.PushFront(key)
+	if g.list.Len() > g.capacity {
+		back := g.list.Back()
+		g.list.Remove(back)
+		delete(g.items, back.Value)
+	}
+}
+
+func (g *ghostList) remove(key interface{}) {
+	if e, ok := g.items[key]; ok {
+		g.list.Remove(e)
+		delete(g.items, key)
+	}
+}
+
+func (g *ghostList) contains(key interface{}) bool {
+	_, ok := g.items[key]
+	return ok
+}
+
+func (g *ghostList) len() int {
+	if g.list == nil {
+		return 0
+	}
+	return g.list.Len()
+}
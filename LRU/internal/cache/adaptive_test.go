@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAdaptiveSplitGrowsOld(t *testing.T) {
+	l := NewLRUCache(intStore(100), 4, 4)
+	l.SetPrefetcher(nil)
+	l.SetAdaptiveSplit(2, 6)
+	// 循环访问 10 个 key：降级到青年区的 key 在再次访问前被淘汰，命中老年区的幽灵链表
+	for round := 0; round < 5; round++ {
+		for k := 0; k < 10; k++ {
+			l.Access(k)
+			if l.Len() > 8 {
+				t.Fatalf("Len() = %d exceeds the total capacity", l.Len())
+			}
+		}
+	}
+	s := l.SplitStats()
+	if s.OldGhostHits == 0 || s.OldCapacity != 6 || s.YoungCapacity != 2 {
+		t.Fatalf("split = %+v, want the old zone grown to its maximum", s)
+	}
+}
+
+func TestAdaptiveSplitGrowsYoung(t *testing.T) {
+	l := NewLRUCache(intStore(1000), 4, 4)
+	l.SetPrefetcher(NewFixedPrefetcher(2))
+	l.SetAdaptiveSplit(0, 0)
+	// 每次未命中预读 4 个邻近的 key，青年区放不下，预读的 key 在被访问之前就被淘汰
+	l.Access(10)
+	for k := 20; k <= 100; k += 10 {
+		l.Access(k)
+		l.Access(k - 9) // 上一次预读的 key，已经被这次的预读挤出青年区
+	}
+	s := l.SplitStats()
+	if s.YoungGhostHits == 0 || s.YoungCapacity <= 4 || s.OldCapacity+s.YoungCapacity != 8 {
+		t.Fatalf("split = %+v, want the young zone grown", s)
+	}
+	if s.MinOld != 1 || s.MaxOld != 7 {
+		t.Fatalf("bounds = [%d, %d], want [1, 7]", s.MinOld, s.MaxOld)
+	}
+}
+
+func TestAdaptiveSplitDisabled(t *testing.T) {
+	l := NewLRUCache(intStore(100), 4, 4)
+	l.SetPrefetcher(nil)
+	for round := 0; round < 3; round++ {
+		for k := 0; k < 10; k++ {
+			l.Access(k)
+		}
+	}
+	s := l.SplitStats()
+	if s.Adaptive || s.OldCapacity != 4 || s.YoungCapacity != 4 || s.OldGhosts != 0 {
+		t.Fatalf("split = %+v, want the fixed split", s)
+	}
+}
+
+func TestAdaptiveSplitConcurrent(t *testing.T) {
+	l := NewLRUCache(intStore(200), 8, 8)
+	l.SetPrefetcher(NewFixedPrefetcher(1))
+	l.SetAdaptiveSplit(0, 0)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				k := (i*(g+1) + g) % 200
+				if v, err := l.Access(k); err != nil || v != k*10 {
+					t.Errorf("Access(%d) = %v, %v", k, v, err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	s := l.SplitStats()
+	if s.OldCapacity+s.YoungCapacity != 16 || l.ZoneLen(ZoneOld) > s.OldCapacity || l.ZoneLen(ZoneYoung) > s.YoungCapacity {
+		t.Fatalf("split = %+v, old %d, young %d", s, l.ZoneLen(ZoneOld), l.ZoneLen(ZoneYoung))
+	}
+}
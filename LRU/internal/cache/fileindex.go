@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// fileEntry 数据文件中一行的位置，Len 不包括行尾的换行符
+type fileEntry struct {
+	Key string
+	Off int64
+	Len int
+}
+
+// fileIndex FileStore 的索引，记录建立索引时数据文件的大小和修改时间，用于判断索引是否过期
+type fileIndex struct {
+	Size    int64
+	ModTime int64 // 修改时间，UnixNano
+	Entries []fileEntry
+
+	keys map[string]int // key -> Entries 中第一次出现的下标，从 Entries 重建，不写入索引文件
+}
+
+// buildIndex 扫描一遍数据文件，记录每个格式正确的行的偏移，格式不正确的行被跳过
+func buildIndex(f *os.File, info os.FileInfo) (*fileIndex, error) {
+	idx := &fileIndex{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+	r := bufio.NewReader(io.NewSectionReader(f, 0, info.Size()))
+	var off int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			n := len(line)
+			content := bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+			if sp := bytes.IndexByte(content, ' '); sp >= 0 {
+				idx.Entries = append(idx.Entries, fileEntry{Key: string(content[:sp]), Off: off, Len: len(content)})
+			}
+			off += int64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	idx.buildKeys()
+	return idx, nil
+}
+
+func (idx *fileIndex) buildKeys() {
+	idx.keys = make(map[string]int, len(idx.Entries))
+	for i, e := range idx.Entries {
+		if _, ok := idx.keys[e.Key]; !ok {
+			idx.keys[e.Key] = i
+		}
+	}
+}
+
+// matches 返回索引是否仍然对应 info 描述的数据文件
+func (idx *fileIndex) matches(info os.FileInfo) bool {
+	return idx != nil && idx.Size == info.Size() && idx.ModTime == info.ModTime().UnixNano()
+}
+
+// read 一次读出 Entries[lo:hi] 覆盖的字节，再按索引切分成键值对
+func (idx *fileIndex) read(f *os.File, lo, hi int) ([]ItemCache, error) {
+	base := idx.Entries[lo].Off
+	last := idx.Entries[hi-1]
+	buf := make([]byte, last.Off+int64(last.Len)-base)
+	if _, err := f.ReadAt(buf, base); err != nil {
+		return nil, err
+	}
+	items := make([]ItemCache, 0, hi-lo)
+	for _, e := range idx.Entries[lo:hi] {
+		line := buf[e.Off-base : e.Off-base+int64(e.Len)]
+		items = append(items, ItemCache{Key: e.Key, Value: string(line[len(e.Key)+1:])})
+	}
+	return items, nil
+}
+
+// indexMagic 索引文件的开头，用于识别格式
+const indexMagic = "LRUIDX1\n"
+
+var errCorruptIndex = errors.New("cache: corrupt index file")
+
+// readIndexFile 加载索引文件，格式见 writeIndexFile
+func readIndexFile(path string) (*fileIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte(indexMagic)) {
+		return nil, errCorruptIndex
+	}
+	data = data[len(indexMagic):]
+	next := func() uint64 {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			err = errCorruptIndex
+			return 0
+		}
+		data = data[n:]
+		return v
+	}
+
+	idx := &fileIndex{Size: int64(next()), ModTime: int64(next())}
+	count := next()
+	if err != nil || count > uint64(len(data)) {
+		return nil, errCorruptIndex
+	}
+	idx.Entries = make([]fileEntry, 0, count)
+	var off int64
+	for i := uint64(0); i < count; i++ {
+		keyLen := next()
+		if err != nil || keyLen > uint64(len(data)) {
+			return nil, errCorruptIndex
+		}
+		key := string(data[:keyLen])
+		data = data[keyLen:]
+		off += int64(next())
+		e := fileEntry{Key: key, Off: off, Len: int(next())}
+		if err != nil || e.Len < len(e.Key)+1 || e.Off+int64(e.Len) > idx.Size {
+			return nil, errCorruptIndex
+		}
+		idx.Entries = append(idx.Entries, e)
+	}
+	idx.buildKeys()
+	return idx, nil
+}
+
+// writeIndexFile 写入索引文件：indexMagic 之后依次是 uvarint 编码的 Size、ModTime、条目数，
+// 以及每个条目的 key 长度、key、与上一条目的偏移差、行长度。
+// 通过 writeFileAtomic 写入，其他进程不会读到写了一半的索引文件
+func writeIndexFile(path string, idx *fileIndex) error {
+	buf := []byte(indexMagic)
+	buf = binary.AppendUvarint(buf, uint64(idx.Size))
+	buf = binary.AppendUvarint(buf, uint64(idx.ModTime))
+	buf = binary.AppendUvarint(buf, uint64(len(idx.Entries)))
+	var off int64
+	for _, e := range idx.Entries {
+		buf = binary.AppendUvarint(buf, uint64(len(e.Key)))
+		buf = append(buf, e.Key...)
+		buf = binary.AppendUvarint(buf, uint64(e.Off-off))
+		buf = binary.AppendUvarint(buf, uint64(e.Len))
+		off = e.Off
+	}
+	return writeFileAtomic(path, buf)
+}
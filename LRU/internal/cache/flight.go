@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrLoadPanic 加载数据时发生了 panic，所有等待这次加载的调用者都会得到包装了它的错误
+var ErrLoadPanic = errors.New("cache: load panicked")
+
+// flightCall 一次正在进行的加载，done 关闭后 value 和 err 不再变化
+type flightCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// flightGroup 合并同一个 key 的并发加载：同时只有一次加载在运行，所有等待者得到相同的结果
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[interface{}]*flightCall
+}
+
+// do 执行或者等待 key 的加载。ctx 只影响当前调用者的等待，不会取消共享的加载；
+// ctx 不可取消时由第一个调用者直接执行 fn，否则 fn 在单独的 goroutine 中执行
+func (g *flightGroup) do(ctx context.Context, key interface{}, fn func() (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[interface{}]*flightCall)
+	}
+	c, ok := g.calls[key]
+	if !ok {
+		c = &flightCall{done: make(chan struct{})}
+		g.calls[key] = c
+		g.mu.Unlock()
+		if ctx.Done() == nil {
+			g.run(key, c, fn)
+			return c.value, c.err
+		}
+		go g.run(key, c, fn)
+	} else {
+		g.mu.Unlock()
+	}
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run 执行 fn 并唤醒所有等待者。fn 的 panic 被转换成错误，
+// 否则在单独的 goroutine 中执行时会使整个进程崩溃，等待者也只能得到 nil
+func (g *flightGroup) run(key interface{}, c *flightCall, fn func() (interface{}, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.value, c.err = nil, fmt.Errorf("%w: %v", ErrLoadPanic, r)
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.value, c.err = fn()
+}
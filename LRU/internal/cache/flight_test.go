@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingStore Load 阻塞到 release 关闭，并记录调用次数
+type blockingStore struct {
+	*MemoryStore
+	loads   atomic.Int32
+	release chan struct{}
+}
+
+func (s *blockingStore) Load(key interface{}) (interface{}, error) {
+	s.loads.Add(1)
+	<-s.release
+	return s.MemoryStore.Load(key)
+}
+
+func newBlockingStore() *blockingStore {
+	return &blockingStore{
+		MemoryStore: NewMemoryStore(ItemCache{Key: "a", Value: 1}),
+		release:     make(chan struct{}),
+	}
+}
+
+// waitLoads 等待 store 的 Load 被调用 n 次
+func waitLoads(t *testing.T, s *blockingStore, n int32) {
+	deadline := time.Now().Add(time.Second)
+	for s.loads.Load() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Load was called %d times, want %d", s.loads.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAccessSingleflight(t *testing.T) {
+	store := newBlockingStore()
+	l := NewLRUCache(store, 0, 0)
+	l.SetPrefetcher(nil)
+
+	const callers = 16
+	var wg sync.WaitGroup
+	results := make(chan interface{}, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _ := l.Access("a")
+			results <- v
+		}()
+	}
+	waitLoads(t, store, 1)
+	time.Sleep(10 * time.Millisecond) // 让其他调用者都进入等待
+	close(store.release)
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != 1 {
+			t.Fatalf("a waiter got %v", v)
+		}
+	}
+	if n := store.loads.Load(); n != 1 {
+		t.Fatalf("Load was called %d times", n)
+	}
+	// 错误同样共享，并且不会被缓存
+	if _, err := l.Access("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Access(missing) error = %v", err)
+	}
+}
+
+func TestAccessContextCancel(t *testing.T) {
+	store := newBlockingStore()
+	l := NewLRUCache(store, 0, 0)
+	l.SetPrefetcher(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := l.AccessContext(ctx, "a")
+		errc <- err
+	}()
+	waitLoads(t, store, 1)
+
+	// 另一个调用者等待同一次加载
+	done := make(chan interface{})
+	go func() {
+		v, _ := l.AccessContext(context.Background(), "a")
+		done <- v
+	}()
+
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled waiter got %v", err)
+	}
+	close(store.release)
+	if v := <-done; v != 1 {
+		t.Fatalf("remaining waiter got %v", v)
+	}
+	// 共享的加载没有被取消，结果已经放入缓存
+	if v, ok := l.Peek("a"); !ok || v != 1 || store.loads.Load() != 1 {
+		t.Fatalf("Peek(a) = (%v, %v) after %d loads", v, ok, store.loads.Load())
+	}
+}
+
+// panicStore Load 在 release 关闭后 panic
+type panicStore struct {
+	*blockingStore
+}
+
+func (s panicStore) Load(key interface{}) (interface{}, error) {
+	s.blockingStore.Load(key)
+	panic("broken store")
+}
+
+func TestAccessLoadPanic(t *testing.T) {
+	store := panicStore{newBlockingStore()}
+	l := NewLRUCache(store, 0, 0)
+	l.SetPrefetcher(nil)
+
+	// 可取消的 ctx 让加载在单独的 goroutine 中执行，panic 不能让进程崩溃
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := l.AccessContext(ctx, "a")
+			errc <- err
+		}()
+	}
+	waitLoads(t, store.blockingStore, 1)
+	close(store.release)
+	for i := 0; i < 3; i++ {
+		if err := <-errc; !errors.Is(err, ErrLoadPanic) {
+			t.Fatalf("waiter got %v, want ErrLoadPanic", err)
+		}
+	}
+
+	// ctx 不可取消时在调用者的 goroutine 中执行，同样返回错误
+	if _, err := l.Access("a"); !errors.Is(err, ErrLoadPanic) {
+		t.Fatalf("Access(a) = %v, want ErrLoadPanic", err)
+	}
+	if _, ok := l.Peek("a"); ok {
+		t.Fatalf("a failed load was cached")
+	}
+}
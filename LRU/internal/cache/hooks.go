@@ -0,0 +1,82 @@
+package cache
+
+import "time"
+
+// Zone 缓存中的区域
+type Zone int
+
+const (
+	// ZoneNone 不在缓存中：未命中，或者被淘汰出缓存
+	ZoneNone Zone = iota
+	// ZoneOld 老年区
+	ZoneOld
+	// ZoneYoung 青年区
+	ZoneYoung
+)
+
+func (z Zone) String() string {
+	switch z {
+	case ZoneOld:
+		return "old"
+	case ZoneYoung:
+		return "young"
+	}
+	return "none"
+}
+
+// Hooks 缓存事件的回调，为 nil 的回调不调用。
+// 回调可能在持有缓存内部锁或者后台 goroutine 中调用，必须并发安全并且不能再访问缓存
+type Hooks struct {
+	// OnAccess 每次 Access 调用，zone 为命中的区，未命中时为 ZoneNone；两区策略以外的策略命中时总是 ZoneOld
+	OnAccess func(key interface{}, zone Zone)
+	// OnEvict 项被淘汰：从老年区降级到青年区（from=ZoneOld, to=ZoneYoung），或者被淘汰出青年区（from=ZoneYoung, to=ZoneNone）。
+	// 两区策略以外的策略只有老年区，淘汰时 from=ZoneOld, to=ZoneNone
+	OnEvict func(key interface{}, from, to Zone)
+	// OnPromote 项从青年区晋升到老年区
+	OnPromote func(key interface{})
+	// OnPrefetch 预读的项放入青年区时 hit 为 false，这个项第一次被访问时 hit 为 true
+	OnPrefetch func(key interface{}, hit bool)
+	// OnLoad 从后端存储加载一个 key 之后调用，包括提前刷新的后台加载
+	OnLoad func(key interface{}, latency time.Duration, err error)
+}
+
+// SetHooks 设置事件回调。可以和其他操作并发调用，之后发生的事件使用新的回调
+func (l *LRUCache) SetHooks(h Hooks) {
+	l.hooks.Store(&h)
+}
+
+// demoted 两区策略把 key 从老年区降级到青年区时调用，调用方持有 mu
+func (l *LRUCache) demoted(key interface{}) {
+	if item, ok := l.items[key]; ok {
+		// 其他 goroutine 可能还持有原来的项，所以复制一份再修改
+		cp := *item
+		cp.demoted = true
+		l.items[key] = &cp
+	}
+	if h := l.hooks.Load(); h.OnEvict != nil {
+		h.OnEvict(key, ZoneOld, ZoneYoung)
+	}
+}
+
+// dropped 项被淘汰出缓存时调用，调用方持有 mu。两区策略下项从青年区淘汰，其他策略下从老年区淘汰
+func (l *LRUCache) dropped(item *ItemCache) {
+	l.evicted(item.Key)
+	l.addGhost(item)
+	from := ZoneOld
+	if l.twoZone != nil {
+		from = ZoneYoung
+	}
+	if h := l.hooks.Load(); h.OnEvict != nil {
+		h.OnEvict(item.Key, from, ZoneNone)
+	}
+}
+
+// load 从后端存储加载 key 并报告耗时
+func (l *LRUCache) load(key interface{}) (interface{}, error) {
+	start := time.Now()
+	value, err := l.store.Load(key)
+	if h := l.hooks.Load(); h.OnLoad != nil {
+		h.OnLoad(key, time.Since(start), err)
+	}
+	return value, err
+}
@@ -1,207 +1,426 @@
 package cache
 
 import (
-	"bufio"
-	"errors"
-	"fmt"
-	"os"
-	"strconv"
-	"strings"
+	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ItemCache 用于在列表和map中存储缓存项
 type ItemCache struct {
 	Key   interface{}
 	Value interface{}
+
+	prefetched bool          // 由预读放入青年区，还没有被访问过
+	demoted    bool          // 从老年区降级到青年区，之后没有被访问过
+	ttl        time.Duration // 存活时间，刷新后按同样的时间重新计算过期时间
+	expireAt   time.Time     // 过期时间，零值表示不过期
+	version    uint64        // 创建时分配，在两个区之间移动时不变，提前刷新据此判断项是否被替换过
 }
 
-// LRUCache 包含老年区和青年区
+const (
+	// DefaultCapacity 老年区和青年区默认的容量
+	DefaultCapacity = 1024
+	// nearbyCount 默认的预读策略在未命中时预读 key 前后各多少个邻近的键值对
+	nearbyCount = 5
+)
+
+// LRUCache 由 Policy 决定淘汰顺序的缓存，默认使用两区策略 TwoZonePolicy：
+// 命中老年区直接返回，命中青年区时晋升到老年区，未命中时从后端存储加载到老年区，并把邻近的键值对预读到青年区
 type LRUCache struct {
-	Old   *OldCache
-	Young *YoungCache
-	sync.RWMutex
+	mu      sync.Mutex
+	items   map[interface{}]*ItemCache // 缓存中的项，由 mu 保护
+	policy  Policy                     // 淘汰顺序，由 mu 保护
+	twoZone *TwoZonePolicy             // policy 是两区策略时指向它，用于区分两个区；其他策略时为 nil
+
+	store      Store                      // 未命中时读取数据的后端存储
+	prefetcher atomic.Pointer[Prefetcher] // 预读策略，可以在使用过程中替换
+	mode       atomic.Int32               // Put 的写入模式（WriteMode），可以在使用过程中修改
+	wb         writeBack                  // 写回模式的脏数据
+
+	ttl          atomic.Int64     // 默认的存活时间（time.Duration），0 表示不过期
+	refreshAhead atomic.Bool      // 访问到过期的项时先返回旧值并在后台重新加载
+	refresh      refreshState     // 提前刷新的状态
+	now          func() time.Time // 当前时间，测试时可以替换
+
+	hooks     atomic.Pointer[Hooks]   // 事件回调，可以在使用过程中替换
+	flight    flightGroup             // 合并同一个 key 的并发加载
+	admission atomic.Pointer[TinyLFU] // 老年区的准入过滤器，nil 表示不过滤
+	split     adaptiveSplit           // 按幽灵链表的命中调整两个区的容量
+}
+
+// NewLRUCache 创建使用 store 作为后端存储、两区策略的 LRUCache，store 为 nil 时未命中直接返回 ErrNotFound。
+// oldCapacity 和 youngCapacity 分别为老年区和青年区的容量，小于等于 0 时使用 DefaultCapacity。
+// 默认的预读策略为未命中时预读前后各 5 个键值对，可以用 SetPrefetcher 替换；
+// 默认的写入模式为 WriteNone，可以用 SetWriteMode 替换；默认不过期，可以用 SetDefaultTTL 设置存活时间
+func NewLRUCache(store Store, oldCapacity, youngCapacity int) *LRUCache {
+	if oldCapacity <= 0 {
+		oldCapacity = DefaultCapacity
+	}
+	if youngCapacity <= 0 {
+		youngCapacity = DefaultCapacity
+	}
+	return NewLRUCacheWithPolicy(store, NewTwoZonePolicy(oldCapacity, youngCapacity))
+}
+
+// NewLRUCacheWithPolicy 创建由 policy 决定淘汰顺序的 LRUCache，容量由 policy 决定，其他默认设置与 NewLRUCache 相同。
+// policy 不能被其他缓存共用。除 TwoZonePolicy 外的策略只有一个区，所有项都算在老年区：
+// 预读的项和加载的项一样交给策略，准入过滤器和自适应划分不起作用
+func NewLRUCacheWithPolicy(store Store, policy Policy) *LRUCache {
+	l := &LRUCache{
+		items:   make(map[interface{}]*ItemCache),
+		policy:  policy,
+		store:   store,
+		wb:      writeBack{dirty: make(map[interface{}]dirtyEntry)},
+		refresh: refreshState{inflight: make(map[interface{}]struct{})},
+		now:     time.Now,
+	}
+	if p, ok := policy.(*TwoZonePolicy); ok {
+		l.twoZone = p
+		p.onDemote = l.demoted
+	}
+	l.SetPrefetcher(NewFixedPrefetcher(nearbyCount))
+	l.hooks.Store(&Hooks{})
+	return l
 }
 
-func NewLRUCache() *LRUCache {
-	return &LRUCache{
-		Old:   NewOldCache(),
-		Young: NewYoungCache(),
+// SetPrefetcher 替换预读策略，p 为 nil 时不预读，可以和其他操作并发调用，
+// 已经开始的访问仍然使用原来的策略
+func (l *LRUCache) SetPrefetcher(p Prefetcher) {
+	if p == nil {
+		p = NewNoPrefetcher()
 	}
+	l.prefetcher.Store(&p)
+}
+
+// Prefetcher 返回当前的预读策略，可以通过它的 Stats 查看预读准确率
+func (l *LRUCache) Prefetcher() Prefetcher {
+	return *l.prefetcher.Load()
 }
 
-// Access 访问缓存中的项，根据空间局部性决定放在老年区还是青年区
+// Access 访问缓存中的项，根据空间局部性决定放在老年区还是青年区，见 AccessContext
 func (l *LRUCache) Access(key interface{}) (interface{}, error) {
-	keyStr, ok := key.(string)
-	if !ok {
-		return nil, fmt.Errorf("invalid key type")
-	}
-	l.RLock()
-	inOld, oldOk := l.Old.Items.Load(key)
-	inYoung, youngOk := l.Young.Items.Load(key)
-	l.RUnlock()
-
-	if oldOk {
-		// 如果数据在老年区，直接返回值
-		return inOld, nil
-	} else if youngOk {
-		// 如果数据在青年区，可以考虑晋升到老年区
-		// 假设 value 不需要，因为可以从青年区项中获取
-		l.Young.PromoteToOld(keyStr, l.Old)
-		return inYoung, nil
-	} else {
-		// 数据不在缓存中，需要从磁盘加载
-		// 这里假设 loadFromDisk 是从磁盘加载数据的函数
-		value := l.loadFromDisk(key.(string))
+	return l.AccessContext(context.Background(), key)
+}
+
+// AccessContext 访问缓存中的项，根据空间局部性决定放在老年区还是青年区。
+// 同一个 key 并发未命中时只加载一次，所有调用者得到相同的值或错误；
+// ctx 结束时当前调用者返回 ctx.Err()，但共享的加载继续进行并放入缓存。
+// 过期的项按 SetRefreshAhead 的设置返回旧值并在后台刷新，或者删除后重新加载。
+// 访问导致脏数据离开缓存时会立即刷新，刷新失败的数据保留为脏，由 Flush 报告错误
+func (l *LRUCache) AccessContext(ctx context.Context, key interface{}) (interface{}, error) {
+	if f := l.admission.Load(); f != nil {
+		f.Record(key)
+	}
+	hooks := l.hooks.Load()
+	item, zone := l.get(key)
 
-		if value != nil {
-			return value, nil
+	// 脏数据比后端存储中的新，不会过期
+	if _, dirty := l.dirtyValue(key); zone != ZoneNone && item.expired(l.now()) && !dirty {
+		if l.refreshAhead.Load() && l.store != nil {
+			l.startRefresh(item)
 		} else {
-			return nil, errors.New("don`t find data")
+			l.mu.Lock()
+			l.removeLocked(key)
+			l.mu.Unlock()
+			zone = ZoneNone
 		}
+	}
 
+	if hooks.OnAccess != nil {
+		hooks.OnAccess(key, zone)
 	}
+
+	if zone == ZoneNone {
+		// 数据不在缓存中，需要从后端存储加载，同一个 key 的并发加载合并为一次
+		l.ghostHit(key)
+		value, err := l.flight.do(ctx, key, func() (interface{}, error) {
+			return l.loadFromStore(key)
+		})
+		if err != nil {
+			return nil, err
+		}
+		l.flushEvicted()
+		return value, nil
+	}
+
+	// 命中时交给策略记录访问，两区策略下命中青年区的项晋升到老年区（准入过滤器允许时）
+	prefetchHit, promoted := l.hit(key)
+	if prefetchHit {
+		l.Prefetcher().Stats().Hits.Add(1)
+		if hooks.OnPrefetch != nil {
+			hooks.OnPrefetch(key, true)
+		}
+	}
+	if promoted && hooks.OnPromote != nil {
+		hooks.OnPromote(key)
+	}
+	// 命中时也通知预读策略，顺序访问、步长访问的策略需要看到完整的访问序列
+	l.prefetch(key, false)
+	l.flushEvicted()
+	return item.Value, nil
 }
 
-// loadFromDisk 从磁盘加载数据到 LRU 缓存中，并考虑空间局部性
-func (l *LRUCache) loadFromDisk(key string) interface{} {
-	filePath := "/home/zwm/go_projects/bash_algorithm/LRU/test/createFile.txt" // 使用正确的文件路径
-	file, err := os.Open(filePath)
-	if err != nil {
-		fmt.Println("Error opening file:", err)
-		return nil
+// Delete 从缓存中删除 key，返回删除前 key 是否在缓存中。
+// 不删除后端存储中的数据，写回模式下被删除的脏数据会先刷新
+func (l *LRUCache) Delete(key interface{}) bool {
+	l.mu.Lock()
+	ok := l.removeLocked(key)
+	if ok {
+		l.evicted(key)
+	}
+	l.mu.Unlock()
+	if ok {
+		l.flushEvicted()
 	}
-	defer file.Close()
+	return ok
+}
 
-	scanner := bufio.NewScanner(file)
-	var loadedValue string
-	lineNumber := 0 // 初始化行号计数器
+// Peek 返回缓存中 key 的值，不晋升、不调整顺序，也不访问后端存储，过期的项视为不存在
+func (l *LRUCache) Peek(key interface{}) (interface{}, bool) {
+	item, zone := l.get(key)
+	if zone == ZoneNone {
+		return nil, false
+	}
+	if _, dirty := l.dirtyValue(key); item.expired(l.now()) && !dirty {
+		return nil, false
+	}
+	return item.Value, true
+}
 
-	for scanner.Scan() {
-		lineNumber++ // 每次循环迭代时递增行号
-		line := scanner.Text()
-		parts := strings.Split(line, " ")
+// Len 返回缓存中项的总数，包括还没有被清理的过期项
+func (l *LRUCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.items)
+}
 
-		if len(parts) > 0 && parts[0] == key { // 确保分割后的数组有足够的元素
-			loadedValue = parts[1]
-			// 将真正访问的 key 对应的数据放入老年区（假设已经在 Access 中处理）
-			l.Old.Add(parts[0], parts[1], l.Young)
+// Capacity 返回缓存的总容量
+func (l *LRUCache) Capacity() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.policy.Capacity()
+}
 
-			// 打印行号，如果需要
-			fmt.Printf("找到所需的数据在第 %d 行\n", lineNumber)
+// Keys 返回所有 key。两区策略下先老年区后青年区，每个区内从新到旧；其他策略下顺序不固定
+func (l *LRUCache) Keys() []interface{} {
+	return append(l.ZoneKeys(ZoneOld), l.ZoneKeys(ZoneYoung)...)
+}
 
-			break // 找到所需的数据，不需要继续扫描
-		}
+// ZoneKeys 返回 z 区中的所有 key。两区策略下从新到旧；其他策略只有老年区，顺序不固定
+func (l *LRUCache) ZoneKeys(z Zone) []interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.twoZone != nil {
+		return l.twoZone.keys(z)
 	}
-
-	if loadedValue == "" {
+	if z != ZoneOld {
 		return nil
 	}
-
-	// 根据空间局部性原则，加载邻近的键
-	nearbyKeys, err := l.nearbyKeysAndValues(lineNumber)
-
-	if err != nil {
-
-		return loadedValue
+	keys := make([]interface{}, 0, len(l.items))
+	for k := range l.items {
+		keys = append(keys, k)
 	}
+	return keys
+}
 
-	for _, meta := range nearbyKeys {
-		// 这里需要调用 loadFromDisk 或其他方法来加载邻近键的数据
-		// 并将它们放入青年区
-		l.Young.Add(meta.Key, meta.Value)
-
+// ZoneLen 返回 z 区中项的数量，两区策略以外的策略只有老年区
+func (l *LRUCache) ZoneLen(z Zone) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.twoZone != nil {
+		return l.twoZone.zoneLen(z)
 	}
+	if z != ZoneOld {
+		return 0
+	}
+	return len(l.items)
+}
 
-	return loadedValue
+// ZoneCapacity 返回 z 区的容量，两区策略以外的策略只有老年区
+func (l *LRUCache) ZoneCapacity(z Zone) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.twoZone != nil {
+		return l.twoZone.zoneCapacity(z)
+	}
+	if z != ZoneOld {
+		return 0
+	}
+	return l.policy.Capacity()
 }
 
-// nearbyKeysAndValues 获取指定行号附近的键值对，不包括目标行本身
-func (l *LRUCache) nearbyKeysAndValues(targetLineNum int) ([]ItemCache, error) {
-	filePath := "/home/zwm/go_projects/bash_algorithm/LRU/test/createFile.txt"
+// Purge 清空缓存，脏数据不会丢失，仍然可以被访问和刷新
+func (l *LRUCache) Purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for k := range l.items {
+		l.policy.Remove(k)
+	}
+	l.items = make(map[interface{}]*ItemCache)
+}
 
-	// 打开文件
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+// get 返回 key 对应的项和所在的区，不记录访问。项放入之后不再修改，更新时整体替换，所以返回的项可以在锁外读取
+func (l *LRUCache) get(key interface{}) (*ItemCache, Zone) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	item, ok := l.items[key]
+	if !ok {
+		return nil, ZoneNone
 	}
-	defer file.Close()
+	return item, l.zoneLocked(key)
+}
 
-	scanner := bufio.NewScanner(file)
-	var items []ItemCache
-	lineNum := 0
-	fmt.Println(targetLineNum, targetLineNum-5, targetLineNum+5)
-	for scanner.Scan() {
-		line := scanner.Text()
-		fmt.Println(lineNum)
-		if lineNum >= targetLineNum-5 && lineNum <= targetLineNum+5 {
-			if lineNum == targetLineNum {
-				lineNum++
-				continue
-			}
-			parts := strings.SplitN(line, " ", 2)
-			items = append(items, ItemCache{Key: parts[0], Value: parts[1]})
-		}
-		if lineNum > targetLineNum+5 {
-			break
-		}
-		lineNum++
+// zoneLocked 返回缓存中的 key 所在的区，调用方持有 mu
+func (l *LRUCache) zoneLocked(key interface{}) Zone {
+	if l.twoZone != nil {
+		return l.twoZone.zone(key)
 	}
+	return ZoneOld
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+// hit 把一次命中交给策略，清除项的预读和降级标记。
+// 返回这是否是预读的项第一次被访问，以及项是否从青年区晋升到了老年区
+func (l *LRUCache) hit(key interface{}) (prefetchHit, promoted bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	item, ok := l.items[key]
+	if !ok {
+		// 已经被其他调用者淘汰或删除
+		return false, false
+	}
+	promoted = l.zoneLocked(key) == ZoneYoung
+	if item.prefetched || item.demoted {
+		// 其他 goroutine 可能还持有原来的项，所以复制一份再修改
+		cp := *item
+		cp.prefetched, cp.demoted = false, false
+		l.items[key] = &cp
+	}
+	if promoted && !l.admitPromotionLocked(key) {
+		l.twoZone.touchYoung(key)
+		promoted = false
+	} else {
+		l.policy.Access(key)
 	}
+	l.evictLocked()
+	return item.prefetched, promoted
+}
 
-	// 检查是否成功找到数据
-	if len(items) == 0 {
-		return nil, errors.New("data not found")
+// addLocked 把不在缓存中的项交给策略，策略允许时放入缓存，调用方持有 mu
+func (l *LRUCache) addLocked(item *ItemCache) {
+	if !l.policy.Admit(item.Key) {
+		// 被拒绝的脏数据和被淘汰的一样需要刷新
+		l.evicted(item.Key)
+		return
 	}
+	l.items[item.Key] = item
+	l.evictLocked()
+}
 
-	return items, nil
+// addYoungLocked 把不在缓存中的项放入青年区，只用于两区策略，调用方持有 mu
+func (l *LRUCache) addYoungLocked(item *ItemCache) {
+	l.twoZone.admitYoung(item.Key)
+	l.items[item.Key] = item
+	l.evictLocked()
 }
-func (l *LRUCache) allKeys() ([]string, error) {
-	return nil, nil
+
+// removeLocked 删除 key，返回删除前 key 是否在缓存中，调用方持有 mu
+func (l *LRUCache) removeLocked(key interface{}) bool {
+	if _, ok := l.items[key]; !ok {
+		return false
+	}
+	delete(l.items, key)
+	l.policy.Remove(key)
+	return true
 }
 
-// contains 检查切片中是否包含特定的元素
-func contains(slice []string, element string) bool {
-	for _, v := range slice {
-		if v == element {
-			return true
+// evictLocked 淘汰策略选出的项直到不超过容量，调用方持有 mu
+func (l *LRUCache) evictLocked() {
+	for {
+		key, ok := l.policy.Victim()
+		if !ok {
+			return
 		}
+		item := l.items[key]
+		delete(l.items, key)
+		l.dropped(item)
 	}
-	return false
 }
 
-// removeElement 从切片中移除特定的元素
-func removeElement(slice []string, element string) []string {
-	for i, v := range slice {
-		if v == element {
-			return append(slice[:i], slice[i+1:]...)
+// loadFromStore 从后端存储加载数据到老年区，并根据空间局部性把邻近的键值对预读到青年区。
+// 离开缓存但还没有刷新成功的脏数据比后端存储中的新，优先使用
+func (l *LRUCache) loadFromStore(key interface{}) (interface{}, error) {
+	value, ok := l.dirtyValue(key)
+	if !ok {
+		if l.store == nil {
+			return nil, ErrNotFound
+		}
+		var err error
+		if value, err = l.load(key); err != nil {
+			return nil, err
 		}
 	}
-	return slice
+	l.mu.Lock()
+	// 加载期间其他调用者可能已经用 Put 写入了更新的值，或者把它预读到了青年区
+	if item, ok := l.items[key]; ok {
+		l.mu.Unlock()
+		return item.Value, nil
+	}
+	l.addLoadedLocked(l.newItem(key, value, l.defaultTTL()))
+	l.mu.Unlock()
+	l.prefetch(key, true)
+	return value, nil
 }
 
-// 解析文件中的每一行，提取键和值
-func parseLine(line string) (key string, value string, err error) {
-	parts := strings.SplitN(line, " ", 2)
-	if len(parts) != 2 {
-		err = errors.New("invalid line format")
+// prefetch 按预读策略把数据读入青年区，预读失败不影响本次访问
+func (l *LRUCache) prefetch(key interface{}, miss bool) {
+	if l.store == nil {
 		return
 	}
-
-	key = parts[0]
-	value, err = strconv.Unquote(`"` + parts[1] + `"`) // 假设值是被双引号包围的
-	return
+	prefetcher, hooks := l.Prefetcher(), l.hooks.Load()
+	req := prefetcher.Prefetch(key, miss)
+	var items []ItemCache
+	if req.Radius > 0 {
+		items, _ = l.store.LoadRange(key, req.Radius)
+	}
+	for _, k := range req.Keys {
+		if _, ok := l.Peek(k); ok {
+			continue
+		}
+		if v, err := l.store.Load(k); err == nil {
+			items = append(items, ItemCache{Key: k, Value: v})
+		}
+	}
+	stats := prefetcher.Stats()
+	for _, item := range items {
+		// 脏 key 在后端存储中是旧值，不能预读
+		if _, ok := l.dirtyValue(item.Key); ok {
+			continue
+		}
+		if l.addPrefetched(l.newItem(item.Key, item.Value, l.defaultTTL())) {
+			stats.Issued.Add(1)
+			if hooks.OnPrefetch != nil {
+				hooks.OnPrefetch(item.Key, false)
+			}
+		}
+	}
 }
 
-// 解析文件的起始行，获取所有键
-func parseAllKeys(line string) map[string]struct{} {
-	allKeys := make(map[string]struct{})
-	// 假设第一行包含所有行号，用空格分隔
-	for _, key := range strings.Fields(line) {
-		allKeys[key] = struct{}{}
+// addPrefetched 把预读的项做上标记放入青年区，其他策略下和加载的项一样交给策略。
+// 已经在缓存中的 key 不再放入，避免覆盖更新的值；返回是否放入
+func (l *LRUCache) addPrefetched(item *ItemCache) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.items[item.Key]; ok {
+		return false
+	}
+	item.prefetched = true
+	if l.twoZone != nil {
+		l.addYoungLocked(item)
+		return true
 	}
-	return allKeys
+	l.addLocked(item)
+	_, ok := l.items[item.Key]
+	return ok
 }
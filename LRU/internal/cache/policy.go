@@ -0,0 +1,386 @@
+package cache
+
+import (
+	"container/list"
+
+	"bash_algorithm/internal/lfu"
+)
+
+// EvictionPolicy LRUCache 满了之后选择淘汰对象的策略
+type EvictionPolicy int
+
+const (
+	PolicyTwoZone EvictionPolicy = iota // 老年区/青年区两个链表，默认策略
+	PolicyLFU                           // 淘汰访问次数最少的，次数相同时淘汰最久没有访问的
+	PolicyARC                           // 自适应替换缓存，按幽灵链表的命中调整最近访问和频繁访问两部分的大小
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case PolicyTwoZone:
+		return "two-zone"
+	case PolicyLFU:
+		return "lfu"
+	case PolicyARC:
+		return "arc"
+	default:
+		return "unknown"
+	}
+}
+
+// Policy 记录 key 的访问情况并选出淘汰对象，由 LRUCache 在自己的锁内调用
+type Policy interface {
+	// Admit 新 key 准备放入缓存时调用，返回 false 表示拒绝，此时 key 不会放入缓存
+	Admit(key interface{}) bool
+	// Access 记录一次对已缓存 key 的访问
+	Access(key interface{})
+	// Update 已缓存的 key 被写入新值
+	Update(key interface{})
+	// Remove key 被删除，不再跟踪
+	Remove(key interface{})
+	// Victim 跟踪的 key 超过容量时选出下一个应该淘汰的 key 并停止跟踪它，没有超过容量时返回 false
+	Victim() (interface{}, bool)
+	// Len 返回跟踪的 key 数量
+	Len() int
+	// Capacity 返回最多保存的 key 数量
+	Capacity() int
+}
+
+// NewPolicy 创建 p 对应的策略，容量为 oldCapacity+youngCapacity；
+// 只有 PolicyTwoZone 区分两个区的容量
+func NewPolicy(p EvictionPolicy, oldCapacity, youngCapacity int) Policy {
+	switch p {
+	case PolicyLFU:
+		return NewLFUPolicy(oldCapacity + youngCapacity)
+	case PolicyARC:
+		return NewARCPolicy(oldCapacity + youngCapacity)
+	default:
+		return NewTwoZonePolicy(oldCapacity, youngCapacity)
+	}
+}
+
+// TwoZonePolicy LRUCache 默认的淘汰策略：新 key 进入老年区，老年区满了把最旧的降级到青年区，
+// 命中青年区时晋升回老年区，命中老年区不调整顺序；青年区满了淘汰青年区最旧的。
+// 预读的项直接放入青年区，见 LRUCache 的 prefetch
+type TwoZonePolicy struct {
+	oldCapacity   int
+	youngCapacity int
+	old, young    *list.List                    // 队首是最新的，元素的值为 key
+	items         map[interface{}]*list.Element // key -> 所在链表的节点
+	zones         map[interface{}]Zone
+	onDemote      func(key interface{}) // 老年区的 key 降级到青年区之后调用
+}
+
+// NewTwoZonePolicy 创建老年区容量为 oldCapacity、青年区容量为 youngCapacity 的两区策略
+func NewTwoZonePolicy(oldCapacity, youngCapacity int) *TwoZonePolicy {
+	return &TwoZonePolicy{
+		oldCapacity:   oldCapacity,
+		youngCapacity: youngCapacity,
+		old:           list.New(),
+		young:         list.New(),
+		items:         make(map[interface{}]*list.Element),
+		zones:         make(map[interface{}]Zone),
+	}
+}
+
+func (p *TwoZonePolicy) Admit(key interface{}) bool {
+	p.pushOld(key)
+	return true
+}
+
+// Access 命中青年区的 key 晋升到老年区
+func (p *TwoZonePolicy) Access(key interface{}) {
+	if p.zones[key] != ZoneYoung {
+		return
+	}
+	p.young.Remove(p.items[key])
+	p.pushOld(key)
+}
+
+// Update 写入的 key 移到老年区队首
+func (p *TwoZonePolicy) Update(key interface{}) {
+	p.Remove(key)
+	p.pushOld(key)
+}
+
+func (p *TwoZonePolicy) Remove(key interface{}) {
+	e, ok := p.items[key]
+	if !ok {
+		return
+	}
+	if p.zones[key] == ZoneOld {
+		p.old.Remove(e)
+	} else {
+		p.young.Remove(e)
+	}
+	delete(p.items, key)
+	delete(p.zones, key)
+}
+
+// Victim 青年区超过容量时淘汰青年区最旧的 key。老年区超过容量时已经降级到青年区，不会直接淘汰
+func (p *TwoZonePolicy) Victim() (interface{}, bool) {
+	if p.young.Len() <= p.youngCapacity {
+		return nil, false
+	}
+	key := p.young.Back().Value
+	p.Remove(key)
+	return key, true
+}
+
+func (p *TwoZonePolicy) Len() int {
+	return len(p.items)
+}
+
+func (p *TwoZonePolicy) Capacity() int {
+	return p.oldCapacity + p.youngCapacity
+}
+
+// zone 返回 key 所在的区
+func (p *TwoZonePolicy) zone(key interface{}) Zone {
+	return p.zones[key]
+}
+
+// admitYoung 把新 key 放到青年区队首，用于预读和准入过滤器拒绝的 key
+func (p *TwoZonePolicy) admitYoung(key interface{}) {
+	p.items[key] = p.young.PushFront(key)
+	p.zones[key] = ZoneYoung
+}
+
+// touchYoung 把青年区中的 key 移到青年区队首，用于准入过滤器拒绝晋升的 key
+func (p *TwoZonePolicy) touchYoung(key interface{}) {
+	p.young.MoveToFront(p.items[key])
+}
+
+// oldVictim 老年区满了时返回下一个将被降级的 key
+func (p *TwoZonePolicy) oldVictim() (interface{}, bool) {
+	if p.old.Len() < p.oldCapacity {
+		return nil, false
+	}
+	return p.old.Back().Value, true
+}
+
+// resize 把老年区的容量调整为 old，青年区的容量相应变化，总容量不变，缩小时把多出来的 key 降级到青年区
+func (p *TwoZonePolicy) resize(old int) {
+	p.youngCapacity += p.oldCapacity - old
+	p.oldCapacity = old
+	for p.old.Len() > p.oldCapacity {
+		p.demote()
+	}
+}
+
+// zoneLen 返回 z 区中 key 的数量
+func (p *TwoZonePolicy) zoneLen(z Zone) int {
+	switch z {
+	case ZoneOld:
+		return p.old.Len()
+	case ZoneYoung:
+		return p.young.Len()
+	}
+	return 0
+}
+
+// zoneCapacity 返回 z 区的容量
+func (p *TwoZonePolicy) zoneCapacity(z Zone) int {
+	switch z {
+	case ZoneOld:
+		return p.oldCapacity
+	case ZoneYoung:
+		return p.youngCapacity
+	}
+	return 0
+}
+
+// keys 按从新到旧的顺序返回 z 区中的 key
+func (p *TwoZonePolicy) keys(z Zone) []interface{} {
+	l := p.old
+	if z == ZoneYoung {
+		l = p.young
+	}
+	keys := make([]interface{}, 0, l.Len())
+	for e := l.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value)
+	}
+	return keys
+}
+
+// pushOld 把 key 放到老年区队首，老年区超过容量时把最旧的降级到青年区
+func (p *TwoZonePolicy) pushOld(key interface{}) {
+	p.items[key] = p.old.PushFront(key)
+	p.zones[key] = ZoneOld
+	if p.old.Len() > p.oldCapacity {
+		p.demote()
+	}
+}
+
+// demote 把老年区最旧的 key 降级到青年区队首
+func (p *TwoZonePolicy) demote() {
+	back := p.old.Back()
+	p.old.Remove(back)
+	p.items[back.Value] = p.young.PushFront(back.Value)
+	p.zones[back.Value] = ZoneYoung
+	if p.onDemote != nil {
+		p.onDemote(back.Value)
+	}
+}
+
+// LFUPolicy 淘汰访问次数最少的 key，次数相同时淘汰最久没有访问的，访问记录由 lfu.LFU 维护
+type LFUPolicy struct {
+	capacity int
+	counts   *lfu.LFU[interface{}]
+}
+
+// NewLFUPolicy 创建容量为 capacity 的 LFU 策略
+func NewLFUPolicy(capacity int) *LFUPolicy {
+	return &LFUPolicy{capacity: capacity, counts: lfu.New[interface{}]()}
+}
+
+func (p *LFUPolicy) Admit(key interface{}) bool {
+	p.counts.Add(key)
+	return true
+}
+
+func (p *LFUPolicy) Access(key interface{}) {
+	p.counts.Access(key)
+}
+
+// Update 写入和访问一样计数
+func (p *LFUPolicy) Update(key interface{}) {
+	p.counts.Access(key)
+}
+
+func (p *LFUPolicy) Remove(key interface{}) {
+	p.counts.Remove(key)
+}
+
+func (p *LFUPolicy) Victim() (interface{}, bool) {
+	if p.counts.Len() <= p.capacity {
+		return nil, false
+	}
+	key, ok := p.counts.Victim()
+	if ok {
+		p.counts.Remove(key)
+	}
+	return key, ok
+}
+
+func (p *LFUPolicy) Len() int {
+	return p.counts.Len()
+}
+
+func (p *LFUPolicy) Capacity() int {
+	return p.capacity
+}
+
+// ARCPolicy 自适应替换缓存（Megiddo & Modha）。t1 保存只访问过一次的 key，t2 保存访问过多次的 key，
+// b1、b2 是从 t1、t2 淘汰的幽灵 key（只记录 key）。新 key 命中 b1 说明 t1 太小，命中 b2 说明 t2 太小，
+// 据此调整 t1 的目标大小 target
+type ARCPolicy struct {
+	capacity       int
+	target         int // t1 的目标大小
+	t1, t2, b1, b2 *list.List
+	items          map[interface{}]*list.Element // key -> 所在链表的节点
+	lists          map[interface{}]*list.List    // key -> 所在的链表
+	ghostHit2      bool                          // 最近一次 Admit 的 key 命中了 b2
+}
+
+// NewARCPolicy 创建容量为 capacity 的 ARC 策略
+func NewARCPolicy(capacity int) *ARCPolicy {
+	return &ARCPolicy{
+		capacity: capacity,
+		t1:       list.New(),
+		t2:       list.New(),
+		b1:       list.New(),
+		b2:       list.New(),
+		items:    make(map[interface{}]*list.Element),
+		lists:    make(map[interface{}]*list.List),
+	}
+}
+
+func (p *ARCPolicy) Admit(key interface{}) bool {
+	p.ghostHit2 = false
+	switch p.lists[key] {
+	case p.b1:
+		p.target = min(p.capacity, p.target+max(p.b2.Len()/p.b1.Len(), 1))
+		p.unlink(key)
+		p.push(p.t2, key)
+	case p.b2:
+		p.target = max(0, p.target-max(p.b1.Len()/p.b2.Len(), 1))
+		p.ghostHit2 = true
+		p.unlink(key)
+		p.push(p.t2, key)
+	default:
+		p.unlink(key) // 已经在缓存中的 key 不应该再次 Admit，这里按新 key 处理
+		p.push(p.t1, key)
+	}
+	p.trimGhosts()
+	return true
+}
+
+func (p *ARCPolicy) Access(key interface{}) {
+	if l := p.lists[key]; l == p.t1 || l == p.t2 {
+		p.unlink(key)
+		p.push(p.t2, key)
+	}
+}
+
+// Update 写入和访问一样把 key 移到 t2
+func (p *ARCPolicy) Update(key interface{}) {
+	p.Access(key)
+}
+
+func (p *ARCPolicy) Remove(key interface{}) {
+	if l := p.lists[key]; l == p.t1 || l == p.t2 {
+		p.unlink(key)
+	}
+}
+
+// Victim 超过容量时，t1 超过目标大小则淘汰 t1 最旧的 key 到 b1，否则淘汰 t2 最旧的 key 到 b2
+func (p *ARCPolicy) Victim() (interface{}, bool) {
+	if p.Len() <= p.capacity {
+		return nil, false
+	}
+	from, ghost := p.t2, p.b2
+	if n := p.t1.Len(); n > 0 && (n > p.target || (n == p.target && p.ghostHit2) || p.t2.Len() == 0) {
+		from, ghost = p.t1, p.b1
+	}
+	back := from.Back()
+	if back == nil {
+		return nil, false
+	}
+	key := back.Value
+	p.unlink(key)
+	p.push(ghost, key)
+	p.trimGhosts()
+	return key, true
+}
+
+func (p *ARCPolicy) Len() int {
+	return p.t1.Len() + p.t2.Len()
+}
+
+func (p *ARCPolicy) Capacity() int {
+	return p.capacity
+}
+
+// trimGhosts 限制幽灵链表的长度：t1+b1 不超过 capacity，四个链表合计不超过 2*capacity
+func (p *ARCPolicy) trimGhosts() {
+	for p.b1.Len() > 0 && p.t1.Len()+p.b1.Len() > p.capacity {
+		p.unlink(p.b1.Back().Value)
+	}
+	for p.b2.Len() > 0 && p.t1.Len()+p.t2.Len()+p.b1.Len()+p.b2.Len() > 2*p.capacity {
+		p.unlink(p.b2.Back().Value)
+	}
+}
+
+func (p *ARCPolicy) push(l *list.List, key interface{}) {
+	p.items[key] = l.PushFront(key)
+	p.lists[key] = l
+}
+
+func (p *ARCPolicy) unlink(key interface{}) {
+	if l, ok := p.lists[key]; ok {
+		l.Remove(p.items[key])
+		delete(p.items, key)
+		delete(p.lists, key)
+	}
+}
@@ -0,0 +1,257 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// policies 共享测试用例使用的所有策略，容量为 oldCapacity+youngCapacity
+var policies = []EvictionPolicy{PolicyTwoZone, PolicyLFU, PolicyARC}
+
+// newPolicyLRU 创建使用策略 p、关闭预读的 LRUCache
+func newPolicyLRU(p EvictionPolicy, store Store, oldCapacity, youngCapacity int) *LRUCache {
+	l := NewLRUCacheWithPolicy(store, NewPolicy(p, oldCapacity, youngCapacity))
+	l.SetPrefetcher(nil)
+	return l
+}
+
+func intStore(n int) *MemoryStore {
+	items := make([]ItemCache, n)
+	for i := range items {
+		items[i] = ItemCache{Key: i, Value: i * 10}
+	}
+	return NewMemoryStore(items...)
+}
+
+func TestPoliciesShared(t *testing.T) {
+	for _, p := range policies {
+		t.Run(p.String(), func(t *testing.T) {
+			c := newPolicyLRU(p, intStore(100), 4, 4)
+
+			// 未命中时从后端存储加载
+			for i := 0; i < 100; i++ {
+				v, err := c.Access(i)
+				if err != nil || v != i*10 {
+					t.Fatalf("Access(%d) = %v, %v", i, v, err)
+				}
+				if c.Len() > 8 {
+					t.Fatalf("Len() = %d exceeds capacity 8", c.Len())
+				}
+			}
+			if c.Len() != 8 || len(c.Keys()) != 8 {
+				t.Fatalf("Len() = %d, Keys() = %v, want 8", c.Len(), c.Keys())
+			}
+			if _, err := c.Access(1000); err != ErrNotFound {
+				t.Fatalf("Access(missing) err = %v, want ErrNotFound", err)
+			}
+
+			// Put 更新已有的值，Peek 不访问后端存储
+			c.Put(99, "x")
+			if v, ok := c.Peek(99); !ok || v != "x" {
+				t.Fatalf("Peek(99) = %v, %v", v, ok)
+			}
+			if _, ok := c.Peek(0); ok {
+				t.Fatal("Peek(0) should not load from the store")
+			}
+			if !c.Delete(99) || c.Delete(99) {
+				t.Fatal("Delete(99) should succeed exactly once")
+			}
+			c.Purge()
+			if c.Len() != 0 {
+				t.Fatalf("Len() after Purge = %d", c.Len())
+			}
+		})
+	}
+}
+
+// 反复访问的热点 key 在顺序扫描后仍然留在缓存中
+func TestPoliciesKeepHotKeys(t *testing.T) {
+	for _, p := range policies {
+		t.Run(p.String(), func(t *testing.T) {
+			c := newPolicyLRU(p, intStore(1000), 4, 4)
+			for round := 0; round < 3; round++ {
+				for k := 0; k < 3; k++ {
+					c.Access(k)
+				}
+			}
+			for k := 100; k < 200; k++ {
+				c.Access(k)
+				c.Access(k % 3)
+			}
+			for k := 0; k < 3; k++ {
+				if _, ok := c.Peek(k); !ok {
+					t.Fatalf("hot key %d was evicted, keys = %v", k, c.Keys())
+				}
+			}
+		})
+	}
+}
+
+func TestPoliciesConcurrent(t *testing.T) {
+	for _, p := range policies {
+		t.Run(p.String(), func(t *testing.T) {
+			c := newPolicyLRU(p, intStore(64), 8, 8)
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := 0; i < 500; i++ {
+						k := (i*7 + g) % 64
+						if i%5 == 0 {
+							c.Put(k, k*10)
+						} else if v, err := c.Access(k); err != nil || v != k*10 {
+							t.Errorf("Access(%d) = %v, %v", k, v, err)
+							return
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+			if c.Len() > 16 {
+				t.Fatalf("Len() = %d exceeds capacity 16", c.Len())
+			}
+		})
+	}
+}
+
+// TestPoliciesCacheFeatures 预读、写回、存活时间和事件回调不依赖于淘汰策略
+func TestPoliciesCacheFeatures(t *testing.T) {
+	for _, p := range policies {
+		t.Run(p.String(), func(t *testing.T) {
+			store := intStore(100)
+			l := NewLRUCacheWithPolicy(store, NewPolicy(p, 2, 2))
+			l.SetPrefetcher(NewFixedPrefetcher(1))
+			clock := &fakeClock{t: time.Unix(0, 0)}
+			l.now = clock.now
+			evictions := 0
+			l.SetHooks(Hooks{OnEvict: func(_ interface{}, _, to Zone) {
+				if to == ZoneNone {
+					evictions++
+				}
+			}})
+
+			// 未命中时预读邻近的 key，之后的访问算作预读命中
+			l.Access(50)
+			if v, err := l.Access(51); err != nil || v != 510 || l.Prefetcher().Stats().Hits.Load() != 1 {
+				t.Fatalf("Access(51) = (%v, %v) with %d prefetch hits", v, err, l.Prefetcher().Stats().Hits.Load())
+			}
+
+			// 写回模式下被淘汰的脏数据写入后端存储
+			l.SetWriteMode(WriteBack)
+			if err := l.Put("dirty", 1); err != nil {
+				t.Fatal(err)
+			}
+			for k := 0; k < 100; k += 10 {
+				l.Access(k)
+			}
+			if v, _ := store.Load("dirty"); v != 1 || len(l.Dirty()) != 0 || evictions == 0 {
+				t.Fatalf("store has %v, dirty %v after %d evictions", v, l.Dirty(), evictions)
+			}
+
+			// 过期的项不再返回，并且可以被清理
+			l.SetWriteMode(WriteNone)
+			l.PutWithTTL("ttl", 1, time.Second)
+			clock.advance(2 * time.Second)
+			if _, ok := l.Peek("ttl"); ok || l.RemoveExpired() != 1 {
+				t.Fatalf("expired entry is still cached, keys = %v", l.Keys())
+			}
+		})
+	}
+}
+
+func TestTwoZonePolicy(t *testing.T) {
+	p := NewTwoZonePolicy(2, 2)
+	var demoted []interface{}
+	p.onDemote = func(key interface{}) { demoted = append(demoted, key) }
+	for _, k := range []string{"a", "b", "c", "d"} {
+		p.Admit(k)
+	}
+	// old: d c，young: b a；命中老年区不调整顺序，命中青年区晋升并把 c 降级
+	p.Access("d")
+	p.Access("a")
+	if _, ok := p.Victim(); ok {
+		t.Fatal("Victim() within capacity reported a key")
+	}
+	// 新 key 把 d 降级，青年区超过容量，淘汰最旧的 b
+	p.Admit("e")
+	var victims []interface{}
+	for k, ok := p.Victim(); ok; k, ok = p.Victim() {
+		victims = append(victims, k)
+	}
+	if got := fmt.Sprint(victims, demoted, p.keys(ZoneOld), p.keys(ZoneYoung)); got != "[b] [a b c d] [e a] [d c]" {
+		t.Fatalf("victims, demoted, old, young = %s, want [b] [a b c d] [e a] [d c]", got)
+	}
+	// 老年区缩小时把多出来的 key 降级，青年区扩大，不淘汰
+	p.resize(1)
+	if _, ok := p.Victim(); ok || fmt.Sprint(p.keys(ZoneYoung)) != "[a d c]" || p.Capacity() != 4 {
+		t.Fatalf("after resize young = %v, capacity %d", p.keys(ZoneYoung), p.Capacity())
+	}
+}
+
+func TestLFUPolicy(t *testing.T) {
+	p := NewLFUPolicy(3)
+	for _, k := range []string{"a", "b", "c"} {
+		p.Admit(k)
+	}
+	p.Access("a")
+	p.Access("a")
+	p.Access("b")
+	if _, ok := p.Victim(); ok {
+		t.Fatal("Victim() within capacity reported a key")
+	}
+	// c 和 d 都只访问过一次，先淘汰更久没有访问的 c，然后是 d
+	var victims []interface{}
+	for _, k := range []string{"d", "e"} {
+		p.Admit(k)
+		for v, ok := p.Victim(); ok; v, ok = p.Victim() {
+			victims = append(victims, v)
+		}
+	}
+	if got := fmt.Sprint(victims); got != "[c d]" {
+		t.Fatalf("victims = %s, want [c d]", got)
+	}
+	if p.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", p.Len())
+	}
+}
+
+func TestARCPolicyAdapts(t *testing.T) {
+	p := NewARCPolicy(4)
+	c := NewLRUCacheWithPolicy(intStore(100), p)
+	c.SetPrefetcher(nil)
+	// 0、1 访问两次进入 t2，之后只访问一次的 2、3 被淘汰到 b1
+	for _, k := range []int{0, 1, 0, 1, 2, 3, 4, 5} {
+		c.Access(k)
+	}
+	if p.target != 0 || p.lists[2] != p.b1 {
+		t.Fatalf("target = %d, key 2 in b1 = %v", p.target, p.lists[2] == p.b1)
+	}
+	// 命中 b1 说明 t1 太小，增大 t1 的目标大小
+	c.Access(2)
+	if p.target == 0 {
+		t.Fatal("a b1 ghost hit should increase the target size of t1")
+	}
+	if p.lists[2] != p.t2 {
+		t.Fatal("a ghost hit should place the key in t2")
+	}
+	// 新的 key 访问两次，把 t2 中原来的 key 淘汰到 b2
+	for k := 10; k < 20; k++ {
+		c.Access(k)
+		c.Access(k)
+	}
+	if p.b2.Len() == 0 {
+		t.Fatal("b2 should hold keys evicted from t2")
+	}
+	before := p.target
+	c.Access(p.b2.Front().Value)
+	if p.target >= before {
+		t.Fatalf("target = %d after a b2 ghost hit, want < %d", p.target, before)
+	}
+	if p.Len() > 4 || p.t1.Len()+p.b1.Len() > 4 || p.Len()+p.b1.Len()+p.b2.Len() > 8 {
+		t.Fatalf("list sizes t1=%d t2=%d b1=%d b2=%d violate the ARC bounds",
+			p.t1.Len(), p.t2.Len(), p.b1.Len(), p.b2.Len())
+	}
+}
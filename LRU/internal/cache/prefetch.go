@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// PrefetchRequest 一次预读的内容：Radius 为存储顺序上 key 前后各预读的数量，Keys 为额外单独预读的 key
+type PrefetchRequest struct {
+	Radius int
+	Keys   []interface{}
+}
+
+// Prefetcher 预读策略，决定访问一个 key 之后把哪些数据预读到青年区
+type Prefetcher interface {
+	// Prefetch 在每次访问 key 时调用，miss 表示 key 不在缓存中
+	Prefetch(key interface{}, miss bool) PrefetchRequest
+	// Stats 返回这个策略的预读统计，由 LRUCache 更新
+	Stats() *PrefetchStats
+}
+
+// PrefetchStats 预读的准确率统计
+type PrefetchStats struct {
+	Issued atomic.Int64 // 实际放入青年区的预读项数量，已经在缓存中的 key 不计
+	Hits   atomic.Int64 // 预读项在被淘汰之前被访问的数量
+}
+
+// Accuracy 返回预读项中后来被访问的比例，还没有预读时返回 0
+func (s *PrefetchStats) Accuracy() float64 {
+	issued := s.Issued.Load()
+	if issued == 0 {
+		return 0
+	}
+	return float64(s.Hits.Load()) / float64(issued)
+}
+
+// NoPrefetcher 不预读
+type NoPrefetcher struct {
+	stats PrefetchStats
+}
+
+// NewNoPrefetcher 创建不预读的策略
+func NewNoPrefetcher() *NoPrefetcher {
+	return &NoPrefetcher{}
+}
+
+func (p *NoPrefetcher) Prefetch(interface{}, bool) PrefetchRequest { return PrefetchRequest{} }
+
+func (p *NoPrefetcher) Stats() *PrefetchStats { return &p.stats }
+
+// FixedPrefetcher 未命中时预读存储顺序上前后各 radius 个键值对
+type FixedPrefetcher struct {
+	radius int
+	stats  PrefetchStats
+}
+
+// NewFixedPrefetcher 创建固定半径的预读策略
+func NewFixedPrefetcher(radius int) *FixedPrefetcher {
+	return &FixedPrefetcher{radius: radius}
+}
+
+func (p *FixedPrefetcher) Prefetch(_ interface{}, miss bool) PrefetchRequest {
+	if !miss {
+		return PrefetchRequest{}
+	}
+	return PrefetchRequest{Radius: p.radius}
+}
+
+func (p *FixedPrefetcher) Stats() *PrefetchStats { return &p.stats }
+
+// SequentialPrefetcher 自适应的顺序预读：key 是整数（或整数字符串）并且连续访问时，
+// 预读后面 window 个 key，每次连续访问窗口翻倍直到 max，顺序被打断后窗口回到 min。
+// 不是顺序访问时，未命中按半径 min 预读
+type SequentialPrefetcher struct {
+	min, max int
+	stats    PrefetchStats
+
+	mu      sync.Mutex
+	last    int
+	hasLast bool
+	window  int
+}
+
+// NewSequentialPrefetcher 创建窗口在 [min, max] 之间变化的顺序预读策略
+func NewSequentialPrefetcher(min, max int) *SequentialPrefetcher {
+	if max < min {
+		max = min
+	}
+	return &SequentialPrefetcher{min: min, max: max, window: min}
+}
+
+func (p *SequentialPrefetcher) Prefetch(key interface{}, miss bool) PrefetchRequest {
+	n, ok := intKey(key)
+	p.mu.Lock()
+	sequential := ok && p.hasLast && n == p.last+1
+	if sequential {
+		p.window *= 2
+		if p.window > p.max {
+			p.window = p.max
+		}
+	} else {
+		p.window = p.min
+	}
+	p.last, p.hasLast = n, ok
+	window := p.window
+	p.mu.Unlock()
+
+	if sequential {
+		return PrefetchRequest{Keys: strideKeys(key, 1, window)}
+	}
+	if miss {
+		return PrefetchRequest{Radius: p.min}
+	}
+	return PrefetchRequest{}
+}
+
+func (p *SequentialPrefetcher) Stats() *PrefetchStats { return &p.stats }
+
+// StridePrefetcher 步长检测：key 是整数（或整数字符串）并且连续两次访问的间隔相同时，
+// 按这个步长预读后面 depth 个 key，否则不预读
+type StridePrefetcher struct {
+	depth int
+	stats PrefetchStats
+
+	mu      sync.Mutex
+	last    int
+	stride  int
+	history int // 已经记录的访问次数，最多记到 2
+}
+
+// NewStridePrefetcher 创建预读深度为 depth 的步长检测策略
+func NewStridePrefetcher(depth int) *StridePrefetcher {
+	return &StridePrefetcher{depth: depth}
+}
+
+func (p *StridePrefetcher) Prefetch(key interface{}, _ bool) PrefetchRequest {
+	n, ok := intKey(key)
+	if !ok {
+		return PrefetchRequest{}
+	}
+	p.mu.Lock()
+	stride := n - p.last
+	detected := p.history == 2 && stride == p.stride && stride != 0
+	if p.history < 2 {
+		p.history++
+	}
+	p.last, p.stride = n, stride
+	p.mu.Unlock()
+
+	if !detected {
+		return PrefetchRequest{}
+	}
+	return PrefetchRequest{Keys: strideKeys(key, stride, p.depth)}
+}
+
+func (p *StridePrefetcher) Stats() *PrefetchStats { return &p.stats }
+
+// intKey 把 int 或整数字符串形式的 key 转换为 int
+func intKey(key interface{}) (int, bool) {
+	switch k := key.(type) {
+	case int:
+		return k, true
+	case string:
+		n, err := strconv.Atoi(k)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// strideKeys 返回 key 之后按 stride 递增的 count 个 key，类型与 key 相同（int 或 string）
+func strideKeys(key interface{}, stride, count int) []interface{} {
+	n, _ := intKey(key)
+	keys := make([]interface{}, 0, count)
+	for i := 1; i <= count; i++ {
+		next := n + stride*i
+		if next < 0 {
+			break
+		}
+		if _, ok := key.(int); ok {
+			keys = append(keys, next)
+		} else {
+			keys = append(keys, strconv.Itoa(next))
+		}
+	}
+	return keys
+}
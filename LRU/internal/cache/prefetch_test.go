@@ -0,0 +1,90 @@
+package cache
+
+import "testing"
+
+// newPrefetchCache 创建后端存储为 0..n-1（int key）的缓存
+func newPrefetchCache(n int, p Prefetcher) *LRUCache {
+	store := NewMemoryStore()
+	for i := 0; i < n; i++ {
+		store.Put(i, i)
+	}
+	l := NewLRUCache(store, 0, 0)
+	l.SetPrefetcher(p)
+	return l
+}
+
+func TestFixedPrefetcherAccuracy(t *testing.T) {
+	l := newPrefetchCache(100, NewFixedPrefetcher(2))
+	l.Access(50) // 预读 48 49 51 52
+	l.Access(51)
+	l.Access(52)
+	l.Access(51) // 已经晋升，不重复计数
+	stats := l.Prefetcher().Stats()
+	if stats.Issued.Load() != 4 || stats.Hits.Load() != 2 || stats.Accuracy() != 0.5 {
+		t.Fatalf("issued %d, hits %d, accuracy %v", stats.Issued.Load(), stats.Hits.Load(), stats.Accuracy())
+	}
+	// 命中时不预读
+	l.Access(52)
+	if stats.Issued.Load() != 4 {
+		t.Fatalf("fixed prefetcher issued on a hit")
+	}
+}
+
+func TestSequentialPrefetcher(t *testing.T) {
+	p := NewSequentialPrefetcher(1, 16)
+	l := newPrefetchCache(1000, p)
+	misses := 0
+	for i := 0; i < 500; i++ {
+		if _, ok := l.Peek(i); !ok {
+			misses++
+		}
+		l.Access(i)
+	}
+	// 窗口很快增长到 16，之后每次访问都命中预读的数据
+	if misses > 3 {
+		t.Fatalf("sequential scan missed %d times", misses)
+	}
+	if acc := p.Stats().Accuracy(); acc < 0.9 {
+		t.Fatalf("accuracy %v, want at least 0.9", acc)
+	}
+	if p.window != 16 {
+		t.Fatalf("window = %d, want 16", p.window)
+	}
+	// 顺序被打断后窗口回到 min
+	l.Access(800)
+	if p.window != 1 {
+		t.Fatalf("window = %d after a random access, want 1", p.window)
+	}
+}
+
+func TestStridePrefetcher(t *testing.T) {
+	l := newPrefetchCache(1000, NewStridePrefetcher(3))
+	for _, k := range []int{0, 10, 20} {
+		l.Access(k)
+	}
+	for _, k := range []int{30, 40, 50} {
+		if _, zone := l.get(k); zone != ZoneYoung {
+			t.Fatalf("key %d was not prefetched, young: %v", k, l.ZoneKeys(ZoneYoung))
+		}
+	}
+	if l.ZoneLen(ZoneYoung) != 3 {
+		t.Fatalf("stride prefetcher loaded %v", l.ZoneKeys(ZoneYoung))
+	}
+	// 整数字符串形式的 key 同样可以检测步长
+	p := NewStridePrefetcher(1)
+	if req := p.Prefetch("10", true); len(req.Keys) != 0 {
+		t.Fatalf("stride detected after one access")
+	}
+	p.Prefetch("15", true)
+	if req := p.Prefetch("20", true); len(req.Keys) != 1 || req.Keys[0] != "25" {
+		t.Fatalf("Prefetch(20) = %v, want [25]", req.Keys)
+	}
+}
+
+func TestNoPrefetcher(t *testing.T) {
+	l := newPrefetchCache(100, nil)
+	l.Access(10)
+	if l.ZoneLen(ZoneYoung) != 0 || l.Prefetcher().Stats().Issued.Load() != 0 {
+		t.Fatalf("prefetch is off but young has %v", l.ZoneKeys(ZoneYoung))
+	}
+}
@@ -0,0 +1,402 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound 后端存储中没有这个 key
+var ErrNotFound = errors.New("cache: key not found")
+
+// ErrInvalidKey key 不能映射到后端存储中的位置，例如 DirStore 中包含路径分隔符的 key
+var ErrInvalidKey = errors.New("cache: invalid key")
+
+// Store 缓存未命中时读取数据的后端存储
+type Store interface {
+	// Load 返回 key 对应的值，key 不存在时返回 ErrNotFound
+	Load(key interface{}) (interface{}, error)
+	// LoadRange 返回存储顺序上 key 前后各 n 个键值对（不包括 key 本身），用于按空间局部性预读
+	LoadRange(key interface{}, n int) ([]ItemCache, error)
+}
+
+// WritableStore 支持写入的后端存储，写回模式和写穿模式需要
+type WritableStore interface {
+	Store
+	// Write 把 items 写入存储，key 已存在时覆盖
+	Write(items []ItemCache) error
+}
+
+// FileStore 按行存储的文件，每行是用空格分隔的 key 和 value，
+// key 按 fmt.Sprint 转换为字符串后比较，value 以字符串返回。
+// 第一次访问时建立 key -> 字节偏移的索引，之后每次查找只需要一次定位读取；
+// 文件的大小或修改时间变化后索引会重建
+type FileStore struct {
+	path      string
+	indexPath string // 索引文件的路径，为空时索引只保存在内存中
+
+	mu      sync.RWMutex
+	index   *fileIndex
+	writeMu sync.Mutex // 串行化 Write 的读-改-写
+}
+
+// NewFileStore 使用 path 指向的文件创建 FileStore，索引只保存在内存中
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// NewIndexedFileStore 使用 path 指向的文件创建 FileStore，并把索引保存到 indexPath，
+// 下次打开时如果数据文件没有变化，直接加载索引而不用重新扫描
+func NewIndexedFileStore(path, indexPath string) *FileStore {
+	return &FileStore{path: path, indexPath: indexPath}
+}
+
+// Load 通过索引定位 key 所在的行并读取，有重复的 key 时返回第一行
+func (s *FileStore) Load(key interface{}) (interface{}, error) {
+	f, idx, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	i, ok := idx.keys[fmt.Sprint(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	items, err := idx.read(f, i, i+1)
+	if err != nil {
+		return nil, err
+	}
+	return items[0].Value, nil
+}
+
+// LoadRange 通过索引定位 key 所在的行，一次读出前后各 n 行
+func (s *FileStore) LoadRange(key interface{}, n int) ([]ItemCache, error) {
+	f, idx, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	i, ok := idx.keys[fmt.Sprint(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	lo, hi := i-n, i+n+1
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(idx.Entries) {
+		hi = len(idx.Entries)
+	}
+	items, err := idx.read(f, lo, hi)
+	if err != nil {
+		return nil, err
+	}
+	return append(items[:i-lo], items[i-lo+1:]...), nil
+}
+
+// open 打开数据文件并返回与之匹配的索引，文件变化后先重建索引。
+// 索引和读取使用同一个打开的文件，即使文件随后被替换也不会读到不一致的内容
+func (s *FileStore) open() (*os.File, *fileIndex, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	s.mu.RLock()
+	idx := s.index
+	s.mu.RUnlock()
+	if idx.matches(info) {
+		return f, idx, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.index.matches(info) {
+		if s.index, err = s.loadIndex(f, info); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+	}
+	return f, s.index, nil
+}
+
+// loadIndex 优先使用索引文件，索引文件不存在、损坏或者过期时扫描数据文件重建，并尽量写回索引文件
+func (s *FileStore) loadIndex(f *os.File, info os.FileInfo) (*fileIndex, error) {
+	if s.indexPath != "" {
+		if idx, err := readIndexFile(s.indexPath); err == nil && idx.matches(info) {
+			return idx, nil
+		}
+	}
+	idx, err := buildIndex(f, info)
+	if err != nil {
+		return nil, err
+	}
+	if s.indexPath != "" {
+		// 索引文件只是加速下次打开，写入失败不影响本次访问
+		_ = writeIndexFile(s.indexPath, idx)
+	}
+	return idx, nil
+}
+
+// Write 把 items 写入文件：已有的 key 替换所在的行，新的 key 按顺序追加到末尾。
+// 先写临时文件并 fsync 再重命名替换原文件，崩溃时文件要么是旧内容要么是新内容
+func (s *FileStore) Write(items []ItemCache) error {
+	values := make(map[string]string, len(items))
+	var order []string
+	for _, item := range items {
+		k, v := fmt.Sprint(item.Key), valueString(item.Value)
+		if k == "" || strings.ContainsAny(k, " \r\n") || strings.ContainsAny(v, "\r\n") {
+			return ErrInvalidKey
+		}
+		if _, ok := values[k]; !ok {
+			order = append(order, k)
+		}
+		values[k] = v
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	var buf bytes.Buffer
+	written := make(map[string]bool, len(values))
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		content := strings.TrimRight(line, "\r\n")
+		if k, _, ok := strings.Cut(content, " "); ok {
+			if v, ok := values[k]; ok {
+				buf.WriteString(k + " " + v + "\n")
+				written[k] = true
+				continue
+			}
+		}
+		buf.WriteString(content + "\n")
+	}
+	for _, k := range order {
+		if !written[k] {
+			buf.WriteString(k + " " + values[k] + "\n")
+		}
+	}
+	if err := writeFileAtomic(s.path, buf.Bytes()); err != nil {
+		return err
+	}
+
+	// 重命名后文件的大小和修改时间一般都会变化，这里直接丢弃索引以免修改时间的精度不够
+	s.mu.Lock()
+	s.index = nil
+	s.mu.Unlock()
+	return nil
+}
+
+// MemoryStore 保存在内存中的存储，按写入顺序排列，主要用于测试
+type MemoryStore struct {
+	mu    sync.RWMutex
+	items []ItemCache
+	index map[interface{}]int // key -> items 中的下标
+}
+
+// NewMemoryStore 使用 items 创建 MemoryStore，items 的顺序即存储顺序
+func NewMemoryStore(items ...ItemCache) *MemoryStore {
+	s := &MemoryStore{index: make(map[interface{}]int)}
+	for _, item := range items {
+		s.Put(item.Key, item.Value)
+	}
+	return s
+}
+
+// Put 写入键值对，key 已存在时原地更新，否则追加到末尾
+func (s *MemoryStore) Put(key, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i, ok := s.index[key]; ok {
+		s.items[i].Value = value
+		return
+	}
+	s.index[key] = len(s.items)
+	s.items = append(s.items, ItemCache{Key: key, Value: value})
+}
+
+func (s *MemoryStore) Write(items []ItemCache) error {
+	for _, item := range items {
+		s.Put(item.Key, item.Value)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Load(key interface{}) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i, ok := s.index[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.items[i].Value, nil
+}
+
+func (s *MemoryStore) LoadRange(key interface{}, n int) ([]ItemCache, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i, ok := s.index[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return neighbours(s.items, i, n), nil
+}
+
+// DirStore 每个 key 对应目录下的一个文件，value 为文件内容（[]byte），存储顺序为文件名的字典序
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore 使用目录 dir 创建 DirStore
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+func (s *DirStore) Load(key interface{}) (interface{}, error) {
+	name, err := s.fileName(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// LoadRange 读取目录中按文件名排序后与 key 相邻的文件，子目录被忽略
+func (s *DirStore) LoadRange(key interface{}, n int) ([]ItemCache, error) {
+	name, err := s.fileName(key)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []ItemCache // 先只填 Key，确定范围后再读文件内容
+	target := -1
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if e.Name() == name {
+			target = len(names)
+		}
+		names = append(names, ItemCache{Key: e.Name()})
+	}
+	if target == -1 {
+		return nil, ErrNotFound
+	}
+
+	items := neighbours(names, target, n)
+	for i := range items {
+		data, err := os.ReadFile(filepath.Join(s.dir, items[i].Key.(string)))
+		if err != nil {
+			return nil, err
+		}
+		items[i].Value = data
+	}
+	return items, nil
+}
+
+// Write 把每个 item 写成一个文件，每个文件都先写临时文件再重命名，但多个文件之间不是原子的
+func (s *DirStore) Write(items []ItemCache) error {
+	for _, item := range items {
+		name, err := s.fileName(item.Key)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(filepath.Join(s.dir, name), []byte(valueString(item.Value))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileName 把 key 转换为目录下的文件名，拒绝可能访问到目录之外的 key
+func (s *DirStore) fileName(key interface{}) (string, error) {
+	name := fmt.Sprint(key)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", ErrInvalidKey
+	}
+	return name, nil
+}
+
+// neighbours 返回 items[i] 前后各 n 个元素的副本，不包括 items[i]
+func neighbours(items []ItemCache, i, n int) []ItemCache {
+	lo, hi := i-n, i+n+1
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(items) {
+		hi = len(items)
+	}
+	result := make([]ItemCache, 0, hi-lo-1)
+	result = append(result, items[lo:i]...)
+	return append(result, items[i+1:hi]...)
+}
+
+// valueString 把写入存储的值转换为字符串，[]byte 按原始内容转换
+func valueString(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// writeFileAtomic 先把 data 写入同目录下的临时文件并 fsync，再重命名为 path，最后 fsync 目录，
+// 保证崩溃后 path 要么是旧内容要么是完整的新内容
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
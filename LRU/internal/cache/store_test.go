@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// storeKeys 返回预读结果中的 key，便于比较
+func storeKeys(items []ItemCache) string {
+	keys := make([]interface{}, len(items))
+	for i, item := range items {
+		keys[i] = item.Key
+	}
+	return fmt.Sprint(keys)
+}
+
+func TestStores(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.txt")
+	files := filepath.Join(dir, "files")
+	os.Mkdir(files, 0o755)
+	os.Mkdir(filepath.Join(files, "sub"), 0o755)
+
+	var content []byte
+	mem := NewMemoryStore()
+	for i := 0; i < 10; i++ {
+		key, value := fmt.Sprint(i), fmt.Sprint("v", i)
+		content = append(content, key+" "+value+"\n"...)
+		mem.Put(key, value)
+		os.WriteFile(filepath.Join(files, key), []byte(value), 0o644)
+	}
+	os.WriteFile(file, content, 0o644)
+
+	stores := map[string]Store{
+		"file":   NewFileStore(file),
+		"memory": mem,
+		"dir":    NewDirStore(files),
+	}
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			v, err := s.Load("3")
+			if err != nil || fmt.Sprintf("%s", v) != "v3" {
+				t.Fatalf("Load(3) = (%v, %v)", v, err)
+			}
+			if _, err := s.Load("missing"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Load(missing) error = %v, want ErrNotFound", err)
+			}
+
+			ranges := map[string]string{
+				"5": "[3 4 6 7]",
+				"0": "[1 2]",
+				"9": "[7 8]",
+			}
+			for key, want := range ranges {
+				items, err := s.LoadRange(key, 2)
+				if err != nil || storeKeys(items) != want {
+					t.Fatalf("LoadRange(%s, 2) = (%v, %v), want %s", key, storeKeys(items), err, want)
+				}
+			}
+			if items, _ := s.LoadRange("5", 1); fmt.Sprintf("%s", items[1].Value) != "v6" {
+				t.Fatalf("LoadRange returned value %v for key 6", items[1].Value)
+			}
+			if _, err := s.LoadRange("missing", 2); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("LoadRange(missing) error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+
+	if _, err := NewDirStore(files).Load("../data.txt"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("DirStore allowed a key outside its directory: %v", err)
+	}
+}
+
+func TestAccessUsesStore(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 20; i++ {
+		store.Put(fmt.Sprint(i), i)
+	}
+	l := NewLRUCache(store, 0, 0)
+
+	if v, err := l.Access("10"); err != nil || v != 10 {
+		t.Fatalf("Access(10) = (%v, %v)", v, err)
+	}
+	if _, zone := l.get("10"); zone != ZoneOld {
+		t.Fatalf("missed key was not added to the old zone")
+	}
+	// 邻近的 key 被预读到青年区
+	for _, k := range []string{"5", "9", "11", "15"} {
+		if _, zone := l.get(k); zone != ZoneYoung {
+			t.Fatalf("neighbour %s was not prefetched", k)
+		}
+	}
+	if _, err := l.Access("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Access(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileStoreIndex(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.txt")
+	indexFile := filepath.Join(dir, "data.idx")
+	os.WriteFile(file, []byte("a 1\r\nbad\nb 2 with spaces\na dup\nc 3"), 0o644)
+
+	s := NewIndexedFileStore(file, indexFile)
+	cases := map[string]string{"a": "1", "b": "2 with spaces", "c": "3"}
+	for k, want := range cases {
+		if v, err := s.Load(k); err != nil || v != want {
+			t.Fatalf("Load(%s) = (%q, %v), want %q", k, v, err, want)
+		}
+	}
+	if items, _ := s.LoadRange("c", 2); storeKeys(items) != "[b a]" || items[1].Value != "dup" {
+		t.Fatalf("LoadRange(c, 2) = %v", items)
+	}
+	if _, err := os.Stat(indexFile); err != nil {
+		t.Fatalf("index file was not written: %v", err)
+	}
+
+	// 数据文件变化后索引重建，索引文件同时更新
+	os.WriteFile(file, []byte("a 10\nd 4\n"), 0o644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(file, later, later)
+	if v, err := s.Load("d"); err != nil || v != "4" {
+		t.Fatalf("Load(d) after rewrite = (%v, %v)", v, err)
+	}
+	if _, err := s.Load("c"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load(c) after rewrite error = %v, want ErrNotFound", err)
+	}
+	info, _ := os.Stat(file)
+	if idx, err := readIndexFile(indexFile); err != nil || !idx.matches(info) {
+		t.Fatalf("index file was not refreshed: %v", err)
+	}
+
+	// 新的 FileStore 直接使用索引文件，损坏的索引文件被忽略
+	if v, err := NewIndexedFileStore(file, indexFile).Load("a"); err != nil || v != "10" {
+		t.Fatalf("Load(a) with index file = (%v, %v)", v, err)
+	}
+	os.WriteFile(indexFile, []byte("garbage"), 0o644)
+	if v, err := NewIndexedFileStore(file, indexFile).Load("a"); err != nil || v != "10" {
+		t.Fatalf("Load(a) with corrupt index file = (%v, %v)", v, err)
+	}
+}
+
+const benchFile = "../../test/createFile.txt"
+
+func BenchmarkFileStoreLoad(b *testing.B) {
+	s := NewFileStore(benchFile)
+	s.Load("0") // 建立索引
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Load(strconv.Itoa(i * 7919 % 10000)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFileStoreLoadRange(b *testing.B) {
+	s := NewFileStore(benchFile)
+	s.Load("0")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.LoadRange(strconv.Itoa(i*7919%10000), nearbyCount); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFileStoreOpen 第一次访问的开销：扫描数据文件建立索引，或者加载索引文件
+func BenchmarkFileStoreOpen(b *testing.B) {
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			NewFileStore(benchFile).Load("9999")
+		}
+	})
+	b.Run("index-file", func(b *testing.B) {
+		indexFile := filepath.Join(b.TempDir(), "createFile.idx")
+		NewIndexedFileStore(benchFile, indexFile).Load("0")
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			NewIndexedFileStore(benchFile, indexFile).Load("9999")
+		}
+	})
+}
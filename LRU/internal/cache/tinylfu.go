@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"hash/maphash"
+	"strconv"
+	"sync"
+)
+
+const (
+	sketchDepth      = 4  // count-min sketch 的行数
+	sketchMaxCount   = 15 // 计数器的上限，4 位计数器足够区分冷热
+	minSketchWidth   = 64 // 容量很小时每行也至少有这么多计数器，减少冲突
+	doorkeeperHash   = 3  // doorkeeper 布隆过滤器的哈希函数个数
+	sampleMultiplier = 10 // 记录 capacity*sampleMultiplier 次访问后衰减一次
+)
+
+// TinyLFU 近似统计 key 访问频率的准入过滤器（Einziger & Friedman）。
+// 访问次数记录在 count-min sketch 中，第一次出现的 key 只记录在 doorkeeper 布隆过滤器里，
+// 只访问一次的 key 不会占用 sketch；每记录一定次数的访问，sketch 中所有计数减半、doorkeeper 清空，
+// 使频率统计跟随访问模式的变化。所有方法都可以并发调用
+type TinyLFU struct {
+	mu         sync.Mutex
+	seed       maphash.Seed
+	sketch     countMinSketch
+	door       doorkeeper
+	additions  int // 上次衰减之后记录的访问次数
+	sampleSize int
+}
+
+// NewTinyLFU 创建统计大约 capacity 个 key 的过滤器，capacity 通常为缓存的容量
+func NewTinyLFU(capacity int) *TinyLFU {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	sampleSize := capacity * sampleMultiplier
+	return &TinyLFU{
+		seed:   maphash.MakeSeed(),
+		sketch: newCountMinSketch(nextPowerOfTwo(max(capacity, minSketchWidth))),
+		// 一个衰减周期内最多有 sampleSize 个不同的 key，每个 key 8 位时误判率约为 3%
+		door:       newDoorkeeper(sampleSize * 8),
+		sampleSize: sampleSize,
+	}
+}
+
+// Record 记录一次对 key 的访问
+func (f *TinyLFU) Record(key interface{}) {
+	h := f.hash(key)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.door.add(h) {
+		f.sketch.increment(h)
+	}
+	f.additions++
+	if f.additions >= f.sampleSize {
+		f.sketch.halve()
+		f.door.clear()
+		f.additions = 0
+	}
+}
+
+// Estimate 返回 key 的估计访问次数，可能偏大但不会偏小（衰减之后的次数）
+func (f *TinyLFU) Estimate(key interface{}) int {
+	h := f.hash(key)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.estimate(h)
+}
+
+// Admit 返回 candidate 的估计访问次数是否高于 victim，candidate 只有在这时才应该替换 victim
+func (f *TinyLFU) Admit(candidate, victim interface{}) bool {
+	hc, hv := f.hash(candidate), f.hash(victim)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.estimate(hc) > f.estimate(hv)
+}
+
+func (f *TinyLFU) estimate(h uint64) int {
+	n := f.sketch.estimate(h)
+	if f.door.contains(h) {
+		n++
+	}
+	return n
+}
+
+// hash 计算 key 的哈希值，int 和 string 以外的 key 按 fmt 的格式转换成字符串
+func (f *TinyLFU) hash(key interface{}) uint64 {
+	switch k := key.(type) {
+	case string:
+		return maphash.String(f.seed, k)
+	case int:
+		return maphash.String(f.seed, strconv.Itoa(k))
+	}
+	return maphash.String(f.seed, valueString(key))
+}
+
+// SetAdmission 设置老年区的准入过滤器，f 为 nil 时不过滤（默认）。
+// 打开后从后端存储加载的 key 只有在估计访问次数高于老年区将被降级的项时才放入老年区，否则放入青年区；
+// 命中青年区的 key 也要经过同样的比较才晋升，否则留在青年区并移到队首。
+// 扫描和只访问一两次的 key 不会把热点数据挤出老年区。Put 写入的 key 不经过过滤，两区策略以外的策略不过滤。
+// 可以和其他操作并发调用，但新的过滤器没有之前的访问记录
+func (l *LRUCache) SetAdmission(f *TinyLFU) {
+	l.admission.Store(f)
+}
+
+// addLoadedLocked 把从后端存储加载的项交给策略，两区策略下被准入过滤器拒绝时放入青年区，调用方持有 mu
+func (l *LRUCache) addLoadedLocked(item *ItemCache) {
+	if f := l.admission.Load(); f != nil && l.twoZone != nil {
+		if victim, ok := l.twoZone.oldVictim(); ok && !f.Admit(item.Key, victim) {
+			l.addYoungLocked(item)
+			return
+		}
+	}
+	l.addLocked(item)
+}
+
+// admitPromotionLocked 返回命中青年区的 key 是否可以晋升到老年区，调用方持有 mu。
+// 老年区满了时晋升会降级老年区最旧的项，所以和加载一样需要经过准入过滤器
+func (l *LRUCache) admitPromotionLocked(key interface{}) bool {
+	f := l.admission.Load()
+	if f == nil {
+		return true
+	}
+	victim, ok := l.twoZone.oldVictim()
+	return !ok || f.Admit(key, victim)
+}
+
+// countMinSketch sketchDepth 行计数器，每行用不同的哈希位置计数，估计值取各行的最小值
+type countMinSketch struct {
+	counters []uint8 // sketchDepth 行，每行 width 个计数器
+	mask     uint64  // width-1
+}
+
+func newCountMinSketch(width int) countMinSketch {
+	return countMinSketch{counters: make([]uint8, sketchDepth*width), mask: uint64(width - 1)}
+}
+
+// index 返回第 row 行中 h 对应的计数器下标，用双重哈希从一个哈希值得到各行的位置
+func (s *countMinSketch) index(h uint64, row int) int {
+	h1, h2 := h&0xffffffff, h>>32|1
+	return row*int(s.mask+1) + int((h1+uint64(row)*h2)&s.mask)
+}
+
+func (s *countMinSketch) increment(h uint64) {
+	for row := 0; row < sketchDepth; row++ {
+		if i := s.index(h, row); s.counters[i] < sketchMaxCount {
+			s.counters[i]++
+		}
+	}
+}
+
+func (s *countMinSketch) estimate(h uint64) int {
+	n := uint8(sketchMaxCount)
+	for row := 0; row < sketchDepth; row++ {
+		n = min(n, s.counters[s.index(h, row)])
+	}
+	return int(n)
+}
+
+// halve 所有计数减半，旧的访问逐渐失去影响
+func (s *countMinSketch) halve() {
+	for i := range s.counters {
+		s.counters[i] >>= 1
+	}
+}
+
+// doorkeeper 布隆过滤器，记录衰减周期内出现过的 key
+type doorkeeper struct {
+	bits []uint64
+	mask uint64 // 位数-1
+}
+
+func newDoorkeeper(bits int) doorkeeper {
+	bits = nextPowerOfTwo(max(bits, 64))
+	return doorkeeper{bits: make([]uint64, bits/64), mask: uint64(bits - 1)}
+}
+
+// add 把 h 加入过滤器，返回加入前是否已经存在
+func (d *doorkeeper) add(h uint64) bool {
+	present := true
+	h1, h2 := h>>32, h&0xffffffff|1
+	for i := uint64(0); i < doorkeeperHash; i++ {
+		bit := (h1 + i*h2) & d.mask
+		if d.bits[bit/64]&(1<<(bit%64)) == 0 {
+			present = false
+			d.bits[bit/64] |= 1 << (bit % 64)
+		}
+	}
+	return present
+}
+
+func (d *doorkeeper) contains(h uint64) bool {
+	h1, h2 := h>>32, h&0xffffffff|1
+	for i := uint64(0); i < doorkeeperHash; i++ {
+		bit := (h1 + i*h2) & d.mask
+		if d.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *doorkeeper) clear() {
+	for i := range d.bits {
+		d.bits[i] = 0
+	}
+}
+
+// nextPowerOfTwo 返回大于等于 n 的最小的 2 的幂
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
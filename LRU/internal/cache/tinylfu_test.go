@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTinyLFUEstimate(t *testing.T) {
+	f := NewTinyLFU(100)
+	for i := 0; i < 5; i++ {
+		f.Record("hot")
+	}
+	f.Record("once")
+	if n := f.Estimate("hot"); n < 5 {
+		t.Fatalf("Estimate(hot) = %d, want >= 5", n)
+	}
+	// 只访问一次的 key 只记录在 doorkeeper 中
+	if n := f.Estimate("once"); n != 1 {
+		t.Fatalf("Estimate(once) = %d, want 1", n)
+	}
+	if n := f.Estimate("never"); n != 0 {
+		t.Fatalf("Estimate(never) = %d, want 0", n)
+	}
+	if !f.Admit("hot", "once") || f.Admit("once", "hot") || f.Admit("never", "once") {
+		t.Fatal("Admit should prefer the more frequent key")
+	}
+}
+
+func TestTinyLFUAging(t *testing.T) {
+	f := NewTinyLFU(10) // 记录 100 次访问后衰减
+	for i := 0; i < 9; i++ {
+		f.Record("hot")
+	}
+	before := f.Estimate("hot")
+	for i := 0; f.additions != 0 || i == 0; i++ {
+		f.Record(i + 1000)
+	}
+	// doorkeeper 被清空，sketch 中的计数减半
+	if after := f.Estimate("hot"); after != (before-1)/2 {
+		t.Fatalf("Estimate(hot) after aging = %d, want %d", after, (before-1)/2)
+	}
+}
+
+// newAdmissionCache 创建后端存储为 0..n-1 的缓存，admission 为 true 时打开准入过滤
+func newAdmissionCache(n, oldCapacity, youngCapacity int, admission bool) *LRUCache {
+	l := NewLRUCache(intStore(n), oldCapacity, youngCapacity)
+	l.SetPrefetcher(nil)
+	if admission {
+		l.SetAdmission(NewTinyLFU(oldCapacity + youngCapacity))
+	}
+	return l
+}
+
+// hitRatio 按 trace 访问缓存，返回命中率
+func hitRatio(l *LRUCache, trace []int) float64 {
+	hits := 0
+	for _, k := range trace {
+		if _, ok := l.Peek(k); ok {
+			hits++
+		}
+		l.Access(k)
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+// zipfTrace 生成 n 次访问、key 在 0..keys-1 之间按 Zipf 分布的序列
+func zipfTrace(seed int64, s float64, keys, n int) []int {
+	z := rand.NewZipf(rand.New(rand.NewSource(seed)), s, 1, uint64(keys-1))
+	trace := make([]int, n)
+	for i := range trace {
+		trace[i] = int(z.Uint64())
+	}
+	return trace
+}
+
+func TestAdmissionScanResistance(t *testing.T) {
+	ratios := make(map[bool]float64)
+	for _, admission := range []bool{false, true} {
+		l := newAdmissionCache(10000, 8, 8, admission)
+		for round := 0; round < 4; round++ {
+			for k := 0; k < 8; k++ {
+				l.Access(k)
+			}
+		}
+		// 长时间扫描只访问一次的 key，期间热点 key 仍然在被访问
+		hits, accesses := 0, 0
+		for k := 1000; k < 5000; k++ {
+			l.Access(k)
+			if k%2 == 0 {
+				hot := k / 2 % 8
+				if _, zone := l.get(hot); zone == ZoneOld {
+					hits++
+				}
+				accesses++
+				l.Access(hot)
+			}
+		}
+		ratios[admission] = float64(hits) / float64(accesses)
+	}
+	t.Logf("old-zone hit ratio of hot keys during the scan: two-zone %.3f, with TinyLFU %.3f", ratios[false], ratios[true])
+	if ratios[true] < 0.9 || ratios[false] >= ratios[true] {
+		t.Fatalf("hot keys were not protected from the scan: %v", ratios)
+	}
+}
+
+// 扫描中每个 key 连续访问两次，第二次命中青年区时也要经过准入过滤器才能晋升
+func TestAdmissionScanTwice(t *testing.T) {
+	for _, admission := range []bool{false, true} {
+		l := newAdmissionCache(10000, 8, 8, admission)
+		for round := 0; round < 4; round++ {
+			for k := 0; k < 8; k++ {
+				l.Access(k)
+			}
+		}
+		// 扫描的 key 比两个区加起来还多，访问次数不超过一个衰减周期
+		for k := 1000; k < 1024; k++ {
+			l.Access(k)
+			l.Access(k)
+		}
+		inOld := 0
+		for k := 0; k < 8; k++ {
+			if _, zone := l.get(k); zone == ZoneOld {
+				inOld++
+			}
+		}
+		if admission && inOld != 8 {
+			t.Fatalf("with TinyLFU %d of 8 hot keys are left in the old zone, old = %v", inOld, l.ZoneKeys(ZoneOld))
+		}
+		if !admission && inOld != 0 {
+			t.Fatalf("without admission %d hot keys survived a scan that fills the old zone", inOld)
+		}
+	}
+}
+
+func TestAdmissionZipfHitRatio(t *testing.T) {
+	for _, s := range []float64{1.01, 1.2} {
+		trace := zipfTrace(1, s, 10000, 50000)
+		lru := hitRatio(newAdmissionCache(10000, 100, 100, false), trace)
+		tiny := hitRatio(newAdmissionCache(10000, 100, 100, true), trace)
+		t.Logf("zipf s=%v: two-zone %.3f, with TinyLFU %.3f", s, lru, tiny)
+		if tiny <= lru {
+			t.Fatalf("zipf s=%v: hit ratio with TinyLFU %.3f is not better than %.3f", s, tiny, lru)
+		}
+	}
+
+	// Zipf 访问中间穿插扫描
+	trace := zipfTrace(2, 1.1, 10000, 25000)
+	mixed := make([]int, 0, len(trace)*2)
+	for i, k := range trace {
+		mixed = append(mixed, k)
+		if i%2 == 0 {
+			mixed = append(mixed, 10000+i) // 后端存储中没有，加载失败，但仍然会被记录
+		}
+	}
+	store := intStore(10000 + len(trace))
+	plain, filtered := NewLRUCache(store, 100, 100), NewLRUCache(store, 100, 100)
+	plain.SetPrefetcher(nil)
+	filtered.SetPrefetcher(nil)
+	filtered.SetAdmission(NewTinyLFU(200))
+	lru, tiny := hitRatio(plain, mixed), hitRatio(filtered, mixed)
+	t.Logf("zipf with scans: two-zone %.3f, with TinyLFU %.3f", lru, tiny)
+	if tiny <= lru {
+		t.Fatalf("zipf with scans: hit ratio with TinyLFU %.3f is not better than %.3f", tiny, lru)
+	}
+}
+
+func BenchmarkTinyLFURecord(b *testing.B) {
+	f := NewTinyLFU(1024)
+	trace := zipfTrace(1, 1.1, 100000, 1<<16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Record(trace[i&(len(trace)-1)])
+	}
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// refreshState 提前刷新模式下正在后台重新加载的 key
+type refreshState struct {
+	mu       sync.Mutex
+	inflight map[interface{}]struct{}
+	versions atomic.Uint64 // 最近分配的项版本号
+}
+
+// expired 返回 i 在 now 时是否已经过期
+func (i *ItemCache) expired(now time.Time) bool {
+	return !i.expireAt.IsZero() && !now.Before(i.expireAt)
+}
+
+// SetDefaultTTL 设置 Put 写入和从后端存储加载的项的存活时间，小于等于 0 表示不过期（默认）。
+// 只影响之后写入或加载的项，可以和其他操作并发调用
+func (l *LRUCache) SetDefaultTTL(ttl time.Duration) {
+	l.ttl.Store(int64(ttl))
+}
+
+// defaultTTL 返回 SetDefaultTTL 设置的存活时间
+func (l *LRUCache) defaultTTL() time.Duration {
+	return time.Duration(l.ttl.Load())
+}
+
+// SetRefreshAhead 打开提前刷新：访问到过期的项时先返回旧值，同时在后台从后端存储重新加载，
+// 加载完成后新值立即写回缓存。关闭时过期的项在访问时被删除并同步重新加载
+func (l *LRUCache) SetRefreshAhead(on bool) {
+	l.refreshAhead.Store(on)
+}
+
+// RemoveExpired 删除缓存中所有过期的项，返回删除的数量。脏数据比后端存储中的新，不会过期
+func (l *LRUCache) RemoveExpired() int {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	removed := 0
+	for key, item := range l.items {
+		if _, dirty := l.dirtyValue(key); item.expired(now) && !dirty {
+			l.removeLocked(key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// StartSweeper 启动后台清理，每隔 interval 调用一次 RemoveExpired，返回的函数停止清理
+func (l *LRUCache) StartSweeper(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				l.RemoveExpired()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+		})
+	}
+}
+
+// newItem 创建存活时间为 ttl 的项，ttl 小于等于 0 时不过期
+func (l *LRUCache) newItem(key, value interface{}, ttl time.Duration) *ItemCache {
+	item := &ItemCache{Key: key, Value: value, ttl: ttl, version: l.refresh.versions.Add(1)}
+	if ttl > 0 {
+		item.expireAt = l.now().Add(ttl)
+	}
+	return item
+}
+
+// startRefresh 在后台重新加载过期的项 stale，同一个 key 同时只有一个加载。
+// 加载完成后立即写回新值，加载期间 key 被 Put 写入或者重新加载过时放弃这次结果。
+// 加载和未命中一样经过 flight，后端存储 panic 时按加载失败处理
+func (l *LRUCache) startRefresh(stale *ItemCache) {
+	key := stale.Key
+	l.refresh.mu.Lock()
+	if _, ok := l.refresh.inflight[key]; ok {
+		l.refresh.mu.Unlock()
+		return
+	}
+	l.refresh.inflight[key] = struct{}{}
+	l.refresh.mu.Unlock()
+
+	go func() {
+		// 加载失败时继续使用旧值，下次访问再重试
+		value, err := l.flight.do(context.Background(), key, func() (interface{}, error) {
+			return l.load(key)
+		})
+		if err == nil {
+			l.replace(l.newItem(key, value, stale.ttl), stale.version)
+		}
+		l.refresh.mu.Lock()
+		delete(l.refresh.inflight, key)
+		l.refresh.mu.Unlock()
+	}()
+}
+
+// replace 缓存中 key 的项版本为 version 时用 item 替换，保留预读和降级标记，不调整顺序
+func (l *LRUCache) replace(item *ItemCache, version uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if cur, ok := l.items[item.Key]; ok && cur.version == version {
+		item.prefetched, item.demoted = cur.prefetched, cur.demoted
+		l.items[item.Key] = item
+	}
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock 测试用的时钟
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTTLCache(store Store) (*LRUCache, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	l := NewLRUCache(store, 2, 2)
+	l.now = clock.now
+	l.SetPrefetcher(nil)
+	return l, clock
+}
+
+func TestTTLExpiry(t *testing.T) {
+	store := NewMemoryStore(ItemCache{Key: "a", Value: 1})
+	l, clock := newTTLCache(store)
+	l.SetDefaultTTL(time.Minute)
+	l.Access("a")
+	l.PutWithTTL("b", 2, time.Second)
+	l.PutWithTTL("c", 3, 0) // 不过期，a 降级到青年区并保留过期时间
+
+	clock.advance(2 * time.Second)
+	if _, ok := l.Peek("b"); ok {
+		t.Fatalf("b should have expired")
+	}
+	if _, ok := l.Peek("a"); !ok {
+		t.Fatalf("a expired early")
+	}
+
+	// 过期后访问从后端存储重新加载
+	store.Put("a", 10)
+	clock.advance(time.Minute)
+	if v, err := l.Access("a"); err != nil || v != 10 {
+		t.Fatalf("Access(a) after expiry = (%v, %v), want 10", v, err)
+	}
+	if _, err := l.Access("b"); err != ErrNotFound {
+		t.Fatalf("Access(b) after expiry error = %v, want ErrNotFound", err)
+	}
+	if v, err := l.Access("c"); err != nil || v != 3 {
+		t.Fatalf("Access(c) = (%v, %v)", v, err)
+	}
+}
+
+func TestTTLSweeper(t *testing.T) {
+	l, clock := newTTLCache(NewMemoryStore())
+	l.SetWriteMode(WriteBack)
+	l.PutWithTTL("a", 1, time.Second)
+	l.Flush()
+	l.PutWithTTL("b", 2, time.Second) // 脏数据不过期
+	l.PutWithTTL("c", 3, time.Hour)
+	clock.advance(time.Minute)
+	if n := l.RemoveExpired(); n != 1 || l.Len() != 2 {
+		t.Fatalf("RemoveExpired() = %d, left %v", n, l.Keys())
+	}
+
+	l.Flush()
+	stop := l.StartSweeper(time.Millisecond)
+	defer stop()
+	deadline := time.Now().Add(time.Second)
+	for l.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("sweeper left %v", l.Keys())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRefreshAhead(t *testing.T) {
+	store := NewMemoryStore(ItemCache{Key: "a", Value: "v1"})
+	l, clock := newTTLCache(store)
+	l.SetDefaultTTL(time.Second)
+	l.SetRefreshAhead(true)
+	l.Access("a")
+
+	store.Put("a", "v2")
+	clock.advance(2 * time.Second)
+	// 先返回旧值，后台重新加载
+	if v, err := l.Access("a"); err != nil || v != "v1" {
+		t.Fatalf("Access(a) = (%v, %v), want the stale value", v, err)
+	}
+	waitRefresh(t, l)
+	// 加载完成时就写回缓存，不需要等下一次访问
+	if v, ok := l.Peek("a"); !ok || v != "v2" {
+		t.Fatalf("Peek(a) after refresh = (%v, %v), want v2", v, ok)
+	}
+	if v, _ := l.Access("a"); v != "v2" {
+		t.Fatalf("Access(a) after refresh = %v, want v2", v)
+	}
+	// 刷新后按同样的存活时间重新计算过期时间
+	if _, ok := l.Peek("a"); !ok {
+		t.Fatalf("refreshed entry is already expired")
+	}
+}
+
+// waitRefresh 等待后台的提前刷新全部结束
+func waitRefresh(t *testing.T, l *LRUCache) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.refresh.mu.Lock()
+		pending := len(l.refresh.inflight)
+		l.refresh.mu.Unlock()
+		if pending == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background refresh did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRefreshAheadKeepsNewerPut(t *testing.T) {
+	store := newBlockingStore()
+	l, clock := newTTLCache(store)
+	l.SetDefaultTTL(time.Second)
+	l.SetRefreshAhead(true)
+	l.Put("a", "v1")
+
+	clock.advance(2 * time.Second)
+	if v, _ := l.Access("a"); v != "v1" {
+		t.Fatalf("Access(a) = %v, want the stale value", v)
+	}
+	// 后台加载还没有返回时写入新值，加载的旧值不能覆盖它
+	waitLoads(t, store, 1)
+	l.Put("a", "v3")
+	close(store.release)
+	waitRefresh(t, l)
+	if v, err := l.Access("a"); err != nil || v != "v3" {
+		t.Fatalf("Access(a) = (%v, %v), want v3", v, err)
+	}
+}
+
+// TestRefreshAheadLoadPanic 后台刷新时后端存储 panic 不能让进程崩溃，之后继续使用旧值并且可以再次刷新
+func TestRefreshAheadLoadPanic(t *testing.T) {
+	store := panicStore{newBlockingStore()}
+	l, clock := newTTLCache(store)
+	l.SetDefaultTTL(time.Second)
+	l.SetRefreshAhead(true)
+	l.Put("a", "v1")
+
+	clock.advance(2 * time.Second)
+	if v, err := l.Access("a"); err != nil || v != "v1" {
+		t.Fatalf("Access(a) = (%v, %v), want the stale value", v, err)
+	}
+	close(store.release)
+	waitRefresh(t, l)
+	if v, err := l.Access("a"); err != nil || v != "v1" {
+		t.Fatalf("Access(a) after a panicking refresh = (%v, %v), want the stale value", v, err)
+	}
+	waitRefresh(t, l)
+	waitLoads(t, store.blockingStore, 2)
+}
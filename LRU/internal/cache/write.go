@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// WriteMode Put 写入缓存时如何同步到后端存储
+type WriteMode int
+
+const (
+	// WriteNone 只写缓存，不写后端存储，默认模式
+	WriteNone WriteMode = iota
+	// WriteThrough 先同步写入后端存储，成功后再写缓存
+	WriteThrough
+	// WriteBack 只写缓存并把 key 标记为脏，脏数据在离开缓存、调用 Flush 或定期刷新时写入后端存储
+	WriteBack
+)
+
+func (m WriteMode) String() string {
+	switch m {
+	case WriteNone:
+		return "none"
+	case WriteThrough:
+		return "write-through"
+	case WriteBack:
+		return "write-back"
+	}
+	return "unknown"
+}
+
+// ErrReadOnlyStore 后端存储没有实现 WritableStore，不能使用写穿或写回模式
+var ErrReadOnlyStore = errors.New("cache: store is read-only")
+
+// dirtyEntry 还没有写入后端存储的值，version 用于判断刷新期间这个 key 是否又被写过
+type dirtyEntry struct {
+	value   interface{}
+	version uint64
+}
+
+// writeBack 写回模式的状态。脏数据以这里为准：离开缓存后刷新失败的 key 仍然保留在 dirty 中，
+// 再次访问时从这里读取而不是读后端存储中的旧值
+type writeBack struct {
+	mu      sync.Mutex
+	dirty   map[interface{}]dirtyEntry
+	version uint64
+	pending []interface{} // 已经离开缓存、等待刷新的脏 key
+
+	flushMu sync.Mutex // 串行化刷新，保证后写入的值不会被先开始的刷新覆盖
+}
+
+// SetWriteMode 设置 Put 的写入模式，可以和其他操作并发调用。
+// 从写回模式切换到其他模式时，已有的脏数据仍然可以通过 Flush 写入
+func (l *LRUCache) SetWriteMode(mode WriteMode) {
+	l.mode.Store(int32(mode))
+}
+
+// WriteMode 返回当前的写入模式
+func (l *LRUCache) WriteMode() WriteMode {
+	return WriteMode(l.mode.Load())
+}
+
+// Put 使用默认的存活时间写入键值对，见 PutWithTTL
+func (l *LRUCache) Put(key, value interface{}) error {
+	return l.PutWithTTL(key, value, l.defaultTTL())
+}
+
+// PutWithTTL 写入键值对，两区策略下写入老年区，ttl 小于等于 0 时不过期。
+// 写穿模式下先写后端存储，失败时不修改缓存；写回模式下只标记为脏。
+// 这两种模式下后端存储不支持写入时返回 ErrReadOnlyStore。
+// 写入导致脏数据离开缓存时会立即刷新，刷新的错误也由 Put 返回
+func (l *LRUCache) PutWithTTL(key, value interface{}, ttl time.Duration) error {
+	ws, writable := l.store.(WritableStore)
+	mode := l.WriteMode()
+	if mode != WriteNone && !writable {
+		return ErrReadOnlyStore
+	}
+	switch mode {
+	case WriteThrough:
+		// 和刷新串行，之前写回模式下留下的旧值不能再覆盖刚写入的值；
+		// 缓存也在锁内更新，并发写入同一个 key 时缓存和后端存储留下的是同一个值
+		l.wb.flushMu.Lock()
+		err := ws.Write([]ItemCache{{Key: key, Value: value}})
+		if err == nil {
+			l.wb.mu.Lock()
+			delete(l.wb.dirty, key)
+			l.wb.mu.Unlock()
+			l.putItem(l.newItem(key, value, ttl))
+		}
+		l.wb.flushMu.Unlock()
+		if err != nil {
+			return err
+		}
+	case WriteBack:
+		l.wb.mu.Lock()
+		l.wb.version++
+		l.wb.dirty[key] = dirtyEntry{value: value, version: l.wb.version}
+		l.wb.mu.Unlock()
+		l.putItem(l.newItem(key, value, ttl))
+	default:
+		l.putItem(l.newItem(key, value, ttl))
+	}
+	return l.flushEvicted()
+}
+
+// putItem 把 Put 写入的项交给策略，两区策略下放入老年区队首，青年区中的旧值移到老年区
+func (l *LRUCache) putItem(item *ItemCache) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.items[item.Key]; !ok {
+		l.addLocked(item)
+		return
+	}
+	l.items[item.Key] = item
+	l.policy.Update(item.Key)
+	l.evictLocked()
+}
+
+// Flush 把所有脏数据写入后端存储，写入失败时脏数据保留，下次刷新重试
+func (l *LRUCache) Flush() error {
+	return l.flush(nil, true)
+}
+
+// Dirty 返回还没有写入后端存储的键值对，顺序不固定
+func (l *LRUCache) Dirty() []ItemCache {
+	l.wb.mu.Lock()
+	defer l.wb.mu.Unlock()
+	items := make([]ItemCache, 0, len(l.wb.dirty))
+	for k, e := range l.wb.dirty {
+		items = append(items, ItemCache{Key: k, Value: e.value})
+	}
+	return items
+}
+
+// StartFlusher 启动后台刷新，每隔 interval 调用一次 Flush，刷新失败时调用 onError（可以为 nil）。
+// 返回的函数停止后台刷新并等待正在进行的刷新结束
+func (l *LRUCache) StartFlusher(interval time.Duration, onError func(error)) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := l.Flush(); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+		})
+	}
+}
+
+// dirtyValue 返回 key 还没有写入后端存储的值
+func (l *LRUCache) dirtyValue(key interface{}) (interface{}, bool) {
+	l.wb.mu.Lock()
+	defer l.wb.mu.Unlock()
+	e, ok := l.wb.dirty[key]
+	return e.value, ok
+}
+
+// evicted key 离开缓存时调用，可能持有 mu，所以只记录脏 key，由 flushEvicted 在操作结束后刷新
+func (l *LRUCache) evicted(key interface{}) {
+	l.wb.mu.Lock()
+	if _, ok := l.wb.dirty[key]; ok {
+		l.wb.pending = append(l.wb.pending, key)
+	}
+	l.wb.mu.Unlock()
+}
+
+// flushEvicted 刷新已经离开缓存的脏 key
+func (l *LRUCache) flushEvicted() error {
+	l.wb.mu.Lock()
+	keys := l.wb.pending
+	l.wb.pending = nil
+	l.wb.mu.Unlock()
+	if len(keys) == 0 {
+		return nil
+	}
+	return l.flush(keys, false)
+}
+
+// flush 把 keys（all 为 true 时为所有脏 key）当前的值写入后端存储，写入期间没有被再次修改的 key 不再是脏的
+func (l *LRUCache) flush(keys []interface{}, all bool) error {
+	l.wb.flushMu.Lock()
+	defer l.wb.flushMu.Unlock()
+
+	l.wb.mu.Lock()
+	var items []ItemCache
+	var versions []uint64
+	if all {
+		for k, e := range l.wb.dirty {
+			items = append(items, ItemCache{Key: k, Value: e.value})
+			versions = append(versions, e.version)
+		}
+	} else {
+		for _, k := range keys {
+			if e, ok := l.wb.dirty[k]; ok {
+				items = append(items, ItemCache{Key: k, Value: e.value})
+				versions = append(versions, e.version)
+			}
+		}
+	}
+	l.wb.mu.Unlock()
+	if len(items) == 0 {
+		return nil
+	}
+
+	ws, ok := l.store.(WritableStore)
+	if !ok {
+		return ErrReadOnlyStore
+	}
+	if err := ws.Write(items); err != nil {
+		return err
+	}
+
+	l.wb.mu.Lock()
+	for i, item := range items {
+		if e, ok := l.wb.dirty[item.Key]; ok && e.version == versions[i] {
+			delete(l.wb.dirty, item.Key)
+		}
+	}
+	l.wb.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// failingStore 写入总是失败的存储
+type failingStore struct {
+	*MemoryStore
+	fail bool
+}
+
+func (s *failingStore) Write(items []ItemCache) error {
+	if s.fail {
+		return errors.New("disk full")
+	}
+	return s.MemoryStore.Write(items)
+}
+
+// dirtyKeys 返回排序后的脏 key
+func dirtyKeys(l *LRUCache) string {
+	var keys []string
+	for _, item := range l.Dirty() {
+		keys = append(keys, fmt.Sprint(item.Key))
+	}
+	sort.Strings(keys)
+	return fmt.Sprint(keys)
+}
+
+func TestFileStoreWrite(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.txt")
+	os.WriteFile(file, []byte("a 1\r\nbad\nb 2\na 3\n"), 0o600)
+	s := NewFileStore(file)
+	s.Load("a") // 建立索引，写入后要失效
+
+	err := s.Write([]ItemCache{{Key: "a", Value: "x"}, {Key: "c", Value: []byte("new")}, {Key: "d", Value: 4}})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != "a x\nbad\nb 2\na x\nc new\nd 4\n" {
+		t.Fatalf("file content %q", data)
+	}
+	if v, err := s.Load("c"); err != nil || v != "new" {
+		t.Fatalf("Load(c) after Write = (%v, %v)", v, err)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0o600 {
+		t.Fatalf("Write changed the file mode to %v", info.Mode())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+	if err := s.Write([]ItemCache{{Key: "bad key", Value: 1}}); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Write accepted a key with a space: %v", err)
+	}
+}
+
+func TestWriteThrough(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data.txt")
+	os.WriteFile(file, []byte("a 1\n"), 0o644)
+	l := NewLRUCache(NewFileStore(file), 0, 0)
+	l.SetWriteMode(WriteThrough)
+	if err := l.Put("a", "2"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if v, _ := NewFileStore(file).Load("a"); v != "2" {
+		t.Fatalf("store has %v after write-through Put", v)
+	}
+	if len(l.Dirty()) != 0 {
+		t.Fatalf("write-through left dirty entries %v", l.Dirty())
+	}
+
+	// 写入失败时缓存不变
+	store := &failingStore{MemoryStore: NewMemoryStore(), fail: true}
+	l = NewLRUCache(store, 0, 0)
+	l.SetWriteMode(WriteThrough)
+	if err := l.Put("a", 1); err == nil || l.Len() != 0 {
+		t.Fatalf("failed write-through Put returned %v and cached %v", err, l.Keys())
+	}
+	// 只读的存储不能写穿或写回
+	l = NewLRUCache(NewDirStore(t.TempDir()), 0, 0)
+	l.store = struct{ Store }{l.store}
+	l.SetWriteMode(WriteBack)
+	if err := l.Put("a", 1); !errors.Is(err, ErrReadOnlyStore) {
+		t.Fatalf("Put on a read-only store = %v, want ErrReadOnlyStore", err)
+	}
+}
+
+func TestWriteBack(t *testing.T) {
+	store := &failingStore{MemoryStore: NewMemoryStore()}
+	l := NewLRUCache(store, 1, 1)
+	l.SetWriteMode(WriteBack)
+	l.Put("a", 1)
+	l.Put("b", 2) // a 降级到青年区，仍然是脏的
+	if _, err := store.Load("a"); !errors.Is(err, ErrNotFound) || dirtyKeys(l) != "[a b]" {
+		t.Fatalf("write-back wrote through, dirty %s", dirtyKeys(l))
+	}
+	if err := l.Put("c", 3); err != nil { // a 离开缓存，立即刷新
+		t.Fatalf("Put: %v", err)
+	}
+	if v, _ := store.Load("a"); v != 1 || dirtyKeys(l) != "[b c]" {
+		t.Fatalf("evicted dirty entry was not flushed: store %v, dirty %s", v, dirtyKeys(l))
+	}
+
+	// 刷新失败的数据保留为脏，再次访问得到的是新值而不是存储中的旧值
+	store.Put("d", "old")
+	store.fail = true
+	l.Put("d", "new")
+	if err := l.Put("e", 5); err == nil {
+		t.Fatalf("Put did not report the failed flush")
+	}
+	if _, ok := l.Peek("b"); ok || dirtyKeys(l) != "[b c d e]" {
+		t.Fatalf("dirty after failed flush %s", dirtyKeys(l))
+	}
+	l.Delete("d")
+	if v, err := l.Access("d"); err != nil || v != "new" {
+		t.Fatalf("Access(d) = (%v, %v), want the dirty value", v, err)
+	}
+	if err := l.Flush(); err == nil {
+		t.Fatalf("Flush did not report the failure")
+	}
+
+	store.fail = false
+	if err := l.Flush(); err != nil || len(l.Dirty()) != 0 {
+		t.Fatalf("Flush = %v, dirty %s", err, dirtyKeys(l))
+	}
+	for k, want := range map[string]interface{}{"b": 2, "c": 3, "d": "new", "e": 5} {
+		if v, _ := store.Load(k); v != want {
+			t.Fatalf("store[%s] = %v, want %v", k, v, want)
+		}
+	}
+}
+
+func TestStartFlusher(t *testing.T) {
+	store := NewMemoryStore()
+	l := NewLRUCache(store, 0, 0)
+	l.SetWriteMode(WriteBack)
+	stop := l.StartFlusher(time.Millisecond, nil)
+	defer stop()
+	l.Put("a", 1)
+	deadline := time.Now().Add(time.Second)
+	for len(l.Dirty()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("background flusher did not flush %s", dirtyKeys(l))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if v, _ := store.Load("a"); v != 1 {
+		t.Fatalf("store has %v after background flush", v)
+	}
+	stop()
+}
+
+// TestSettersConcurrent 写入模式、存活时间、提前刷新、预读策略、回调和准入过滤器
+// 可以在其他 goroutine 访问缓存时修改，用 -race 运行
+func TestSettersConcurrent(t *testing.T) {
+	store := NewMemoryStore(ItemCache{Key: "a", Value: 1})
+	l := NewLRUCache(store, 4, 4)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			l.Put(i%8, i)
+			l.Access("a")
+			l.Access(100 + i) // 未命中时经过准入过滤器、预读和加载回调
+		}
+	}()
+	modes := []WriteMode{WriteThrough, WriteBack, WriteNone}
+	for i := 0; i < 200; i++ {
+		l.SetWriteMode(modes[i%len(modes)])
+		l.SetDefaultTTL(time.Duration(i%3) * time.Minute)
+		l.SetRefreshAhead(i%2 == 0)
+		l.SetPrefetcher(NewSequentialPrefetcher(1, 4))
+		l.SetHooks(Hooks{OnAccess: func(interface{}, Zone) {}, OnLoad: func(interface{}, time.Duration, error) {}})
+		l.SetAdmission(NewTinyLFU(8))
+	}
+	<-done
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
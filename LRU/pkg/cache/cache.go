@@ -0,0 +1,325 @@
+// Package cache 对外提供的两区（老年区/青年区）LRU 缓存。
+//
+// 命中老年区直接返回；命中青年区时晋升到老年区；未命中时从后端存储加载到老年区，
+// 并把存储顺序上相邻的键值对预读到青年区。老年区淘汰的项降级到青年区，青年区淘汰的项被丢弃。
+// 也可以用 WithPolicy 换成只有一个区的 LFU 或 ARC 淘汰策略
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	internal "bash_algorithm/LRU/internal/cache"
+	"bash_algorithm/LRU/pkg/metrics"
+)
+
+type (
+	// Store 缓存未命中时读取数据的后端存储
+	Store = internal.Store
+	// Item 一个键值对
+	Item = internal.ItemCache
+	// FileStore 按行存储 "key value" 的文件
+	FileStore = internal.FileStore
+	// MemoryStore 保存在内存中的存储
+	MemoryStore = internal.MemoryStore
+	// DirStore 每个 key 对应目录下一个文件的存储
+	DirStore = internal.DirStore
+
+	// Prefetcher 预读策略，决定访问一个 key 之后把哪些数据预读到青年区
+	Prefetcher = internal.Prefetcher
+	// PrefetchRequest 一次预读的内容
+	PrefetchRequest = internal.PrefetchRequest
+	// PrefetchStats 预读的准确率统计
+	PrefetchStats = internal.PrefetchStats
+
+	// WritableStore 支持写入的后端存储，写穿和写回模式需要
+	WritableStore = internal.WritableStore
+	// WriteMode Put 写入缓存时如何同步到后端存储
+	WriteMode = internal.WriteMode
+
+	// SplitStats 老年区和青年区当前的容量划分
+	SplitStats = internal.SplitStats
+)
+
+const (
+	// WriteNone 只写缓存，不写后端存储，默认模式
+	WriteNone = internal.WriteNone
+	// WriteThrough 先同步写入后端存储，成功后再写缓存
+	WriteThrough = internal.WriteThrough
+	// WriteBack 只写缓存并标记为脏，脏数据在离开缓存、调用 Flush 或定期刷新时写入后端存储
+	WriteBack = internal.WriteBack
+)
+
+var (
+	// ErrNotFound 缓存和后端存储中都没有这个 key
+	ErrNotFound = internal.ErrNotFound
+	// ErrInvalidKey key 不能映射到后端存储中的位置
+	ErrInvalidKey = internal.ErrInvalidKey
+	// ErrReadOnlyStore 后端存储不支持写入，不能使用写穿或写回模式
+	ErrReadOnlyStore = internal.ErrReadOnlyStore
+	// ErrLoadPanic 从后端存储加载时发生了 panic
+	ErrLoadPanic = internal.ErrLoadPanic
+)
+
+// NewFileStore 使用按行存储的文件创建 Store
+func NewFileStore(path string) *FileStore {
+	return internal.NewFileStore(path)
+}
+
+// NewIndexedFileStore 使用按行存储的文件创建 Store，并把 key 的偏移索引保存到 indexPath
+func NewIndexedFileStore(path, indexPath string) *FileStore {
+	return internal.NewIndexedFileStore(path, indexPath)
+}
+
+// NewMemoryStore 使用 items 创建内存中的 Store
+func NewMemoryStore(items ...Item) *MemoryStore {
+	return internal.NewMemoryStore(items...)
+}
+
+// NewDirStore 使用目录 dir 创建 Store
+func NewDirStore(dir string) *DirStore {
+	return internal.NewDirStore(dir)
+}
+
+// NoPrefetch 不预读
+func NoPrefetch() Prefetcher {
+	return internal.NewNoPrefetcher()
+}
+
+// FixedPrefetch 未命中时预读存储顺序上前后各 radius 个键值对，默认策略为 FixedPrefetch(5)
+func FixedPrefetch(radius int) Prefetcher {
+	return internal.NewFixedPrefetcher(radius)
+}
+
+// SequentialPrefetch 顺序访问整数 key 时预读后面的 key，窗口随连续访问从 min 翻倍增长到 max
+func SequentialPrefetch(min, max int) Prefetcher {
+	return internal.NewSequentialPrefetcher(min, max)
+}
+
+// StridePrefetch 检测到整数 key 按固定步长访问时，按步长预读后面 depth 个 key
+func StridePrefetch(depth int) Prefetcher {
+	return internal.NewStridePrefetcher(depth)
+}
+
+// Cache 并发安全的缓存。Cache 本身不加锁，所有方法直接调用内部的缓存，由它自己的锁保证并发安全，
+// 未命中时的加载不会阻塞其他操作
+type Cache struct {
+	c         *internal.LRUCache
+	stopFlush func() // 停止后台刷新，没有启动时为 nil
+	stopSweep func() // 停止后台清理过期项，没有启动时为 nil
+}
+
+type options struct {
+	oldCapacity   int
+	youngCapacity int
+	store         Store
+	prefetcher    Prefetcher
+	writeMode     WriteMode
+	flushInterval time.Duration
+	ttl           time.Duration
+	refreshAhead  bool
+	sweepInterval time.Duration
+	hooks         []Hooks
+	metrics       *metrics.Collector
+	policy        EvictionPolicy
+	admission     bool
+	adaptive      bool
+	minOld        int
+	maxOld        int
+}
+
+// Option 创建 Cache 时的配置项
+type Option func(*options)
+
+// WithOldCapacity 设置老年区的容量，默认 1024
+func WithOldCapacity(n int) Option {
+	return func(o *options) { o.oldCapacity = n }
+}
+
+// WithYoungCapacity 设置青年区的容量，默认 1024
+func WithYoungCapacity(n int) Option {
+	return func(o *options) { o.youngCapacity = n }
+}
+
+// WithStore 设置后端存储，不设置时未命中直接返回 ErrNotFound
+func WithStore(s Store) Option {
+	return func(o *options) { o.store = s }
+}
+
+// WithPrefetcher 设置预读策略，默认为 FixedPrefetch(5)
+func WithPrefetcher(p Prefetcher) Option {
+	return func(o *options) { o.prefetcher = p }
+}
+
+// WithWriteMode 设置 Put 的写入模式，默认为 WriteNone
+func WithWriteMode(mode WriteMode) Option {
+	return func(o *options) { o.writeMode = mode }
+}
+
+// WithFlushInterval 写回模式下每隔 d 把脏数据写入后端存储，刷新失败的数据保留到下次刷新
+func WithFlushInterval(d time.Duration) Option {
+	return func(o *options) { o.flushInterval = d }
+}
+
+// WithTTL 设置默认的存活时间，Put 写入和从后端存储加载的项过期后重新加载，默认不过期
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) { o.ttl = ttl }
+}
+
+// WithRefreshAhead 访问到过期的项时先返回旧值，同时在后台从后端存储重新加载
+func WithRefreshAhead() Option {
+	return func(o *options) { o.refreshAhead = true }
+}
+
+// WithSweepInterval 每隔 d 在后台删除过期的项，默认只在访问时删除
+func WithSweepInterval(d time.Duration) Option {
+	return func(o *options) { o.sweepInterval = d }
+}
+
+// WithTinyLFU 在老年区前加上 TinyLFU 准入过滤器：从后端存储加载和从青年区晋升的 key 只有在估计访问次数高于
+// 老年区将被降级的项时才进入老年区，否则留在青年区，扫描和只访问一两次的 key 不会把热点数据挤出老年区
+func WithTinyLFU() Option {
+	return func(o *options) { o.admission = true }
+}
+
+// WithAdaptiveSplit 两个区的总容量不变，根据最近被淘汰的 key 的再次访问在两个区之间移动容量，
+// 老年区的容量保持在 [minOld, maxOld] 之间，为 0 时不限制（两个区至少各有 1 个位置）。
+// 当前的划分可以通过 SplitStats 和监控中各区的容量查看
+func WithAdaptiveSplit(minOld, maxOld int) Option {
+	return func(o *options) {
+		o.adaptive = true
+		o.minOld, o.maxOld = minOld, maxOld
+	}
+}
+
+// New 创建 Cache。使用 PolicyTwoZone 以外的淘汰策略时设置了只有两区缓存支持的配置项，返回 ErrUnsupported
+func New(opts ...Option) (*Cache, error) {
+	o := options{oldCapacity: internal.DefaultCapacity, youngCapacity: internal.DefaultCapacity}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.policy != PolicyTwoZone {
+		if name := o.twoZoneOnly(); name != "" {
+			return nil, fmt.Errorf("%w: %s with policy %v", ErrUnsupported, name, o.policy)
+		}
+	}
+	if o.oldCapacity <= 0 {
+		o.oldCapacity = internal.DefaultCapacity
+	}
+	if o.youngCapacity <= 0 {
+		o.youngCapacity = internal.DefaultCapacity
+	}
+	c := internal.NewLRUCacheWithPolicy(o.store, internal.NewPolicy(o.policy, o.oldCapacity, o.youngCapacity))
+	if o.prefetcher != nil {
+		c.SetPrefetcher(o.prefetcher)
+	}
+	c.SetWriteMode(o.writeMode)
+	c.SetDefaultTTL(o.ttl)
+	c.SetRefreshAhead(o.refreshAhead)
+	if o.admission {
+		c.SetAdmission(internal.NewTinyLFU(o.oldCapacity + o.youngCapacity))
+	}
+	if o.adaptive {
+		c.SetAdaptiveSplit(o.minOld, o.maxOld)
+	}
+	if o.metrics != nil {
+		o.hooks = append(o.hooks, metricsHooks(o.metrics))
+		o.metrics.SetOccupancy(occupancy(c))
+	}
+	if len(o.hooks) > 0 {
+		c.SetHooks(combineHooks(o.hooks))
+	}
+	cache := &Cache{c: c}
+	if o.flushInterval > 0 {
+		cache.stopFlush = c.StartFlusher(o.flushInterval, nil)
+	}
+	if o.sweepInterval > 0 {
+		cache.stopSweep = c.StartSweeper(o.sweepInterval)
+	}
+	return cache, nil
+}
+
+// PrefetchStats 返回当前预读策略的准确率统计
+func (c *Cache) PrefetchStats() *PrefetchStats {
+	return c.c.Prefetcher().Stats()
+}
+
+// SplitStats 返回老年区和青年区当前的容量划分，使用 PolicyTwoZone 以外的淘汰策略时为零值
+func (c *Cache) SplitStats() SplitStats {
+	return c.c.SplitStats()
+}
+
+// Get 返回 key 的值。两区策略下命中青年区时晋升到老年区，未命中时从后端存储加载，
+// 缓存和后端存储中都没有时返回 ErrNotFound。同一个 key 并发未命中时只加载一次
+func (c *Cache) Get(key interface{}) (interface{}, error) {
+	return c.c.Access(key)
+}
+
+// GetContext 和 Get 相同，但 ctx 结束时不再等待加载并返回 ctx.Err()，
+// 共享的加载不会被取消，完成后仍然放入缓存供其他调用者使用
+func (c *Cache) GetContext(ctx context.Context, key interface{}) (interface{}, error) {
+	return c.c.AccessContext(ctx, key)
+}
+
+// Put 把键值对写入缓存（两区策略下写入老年区），并按写入模式同步到后端存储
+func (c *Cache) Put(key, value interface{}) error {
+	return c.c.Put(key, value)
+}
+
+// PutWithTTL 写入存活时间为 ttl 的键值对，ttl 小于等于 0 时不过期，和 Put 相同
+func (c *Cache) PutWithTTL(key, value interface{}, ttl time.Duration) error {
+	return c.c.PutWithTTL(key, value, ttl)
+}
+
+// Flush 把所有脏数据写入后端存储
+func (c *Cache) Flush() error {
+	return c.c.Flush()
+}
+
+// Dirty 返回还没有写入后端存储的键值对，顺序不固定
+func (c *Cache) Dirty() []Item {
+	return c.c.Dirty()
+}
+
+// Close 停止后台刷新和清理，并把脏数据写入后端存储
+func (c *Cache) Close() error {
+	if c.stopFlush != nil {
+		c.stopFlush()
+	}
+	if c.stopSweep != nil {
+		c.stopSweep()
+	}
+	return c.Flush()
+}
+
+// Delete 从缓存中删除 key，返回删除前 key 是否在缓存中，不影响后端存储
+func (c *Cache) Delete(key interface{}) bool {
+	return c.c.Delete(key)
+}
+
+// Peek 返回缓存中 key 的值，不晋升、不调整顺序，也不访问后端存储
+func (c *Cache) Peek(key interface{}) (interface{}, bool) {
+	return c.c.Peek(key)
+}
+
+// Contains 返回 key 是否在缓存中，不影响顺序
+func (c *Cache) Contains(key interface{}) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// Len 返回缓存中项的数量
+func (c *Cache) Len() int {
+	return c.c.Len()
+}
+
+// Keys 返回缓存中所有的 key，两区策略下先老年区后青年区，每个区内从新到旧；其他策略下顺序不固定
+func (c *Cache) Keys() []interface{} {
+	return c.c.Keys()
+}
+
+// Purge 清空缓存
+func (c *Cache) Purge() {
+	c.c.Purge()
+}
@@ -0,0 +1,368 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"bash_algorithm/LRU/pkg/metrics"
+)
+
+// newCache 创建 Cache，配置项不兼容时测试失败
+func newCache(t *testing.T, opts ...Option) *Cache {
+	t.Helper()
+	c, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCache(t *testing.T) {
+	c := newCache(t, WithOldCapacity(2), WithYoungCapacity(2))
+	c.Put(1, "a") // 非 string 的 key
+	c.Put("b", 2)
+	if v, err := c.Get(1); err != nil || v != "a" {
+		t.Fatalf("Get(1) = (%v, %v)", v, err)
+	}
+	if _, err := c.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	// 老年区满了，最旧的 1 降级到青年区，命中老年区不调整顺序
+	c.Put("c", 3)
+	if got := fmt.Sprint(c.Keys()); got != "[c b 1]" || c.Len() != 3 {
+		t.Fatalf("Keys() = %s, Len() = %d", got, c.Len())
+	}
+
+	// Peek 不晋升
+	if v, ok := c.Peek(1); !ok || v != "a" {
+		t.Fatalf("Peek(1) = (%v, %v)", v, ok)
+	}
+	if got := fmt.Sprint(c.Keys()); got != "[c b 1]" {
+		t.Fatalf("Peek changed the order: %s", got)
+	}
+	// Get 晋升到老年区，老年区最旧的 b 降级
+	c.Get(1)
+	if got := fmt.Sprint(c.Keys()); got != "[1 c b]" {
+		t.Fatalf("Get did not promote: %s", got)
+	}
+
+	if !c.Delete("c") || c.Delete("c") || c.Contains("c") {
+		t.Fatalf("Delete should report whether the key was cached")
+	}
+	c.Purge()
+	if c.Len() != 0 || c.Contains(1) {
+		t.Fatalf("Purge left %d items", c.Len())
+	}
+}
+
+func TestCacheStore(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 100; i++ {
+		store.Put(i, i*i)
+	}
+	c := newCache(t, WithStore(store), WithOldCapacity(4), WithYoungCapacity(16))
+	if v, err := c.Get(50); err != nil || v != 2500 {
+		t.Fatalf("Get(50) = (%v, %v)", v, err)
+	}
+	if !c.Contains(51) || !c.Contains(45) {
+		t.Fatalf("neighbours were not prefetched: %v", c.Keys())
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				k := (w*13 + i) % 100
+				if v, err := c.Get(k); err != nil || v != k*k {
+					t.Errorf("Get(%d) = (%v, %v)", k, v, err)
+					return
+				}
+				c.Peek(k + 1)
+			}
+		}(w)
+	}
+	wg.Wait()
+	if c.Len() > 20 {
+		t.Fatalf("Len() = %d exceeds the configured capacities", c.Len())
+	}
+}
+
+func TestCachePrefetch(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 100; i++ {
+		store.Put(i, i)
+	}
+	c := newCache(t, WithStore(store), WithPrefetcher(StridePrefetch(2)))
+	for _, k := range []int{1, 4, 7, 10, 13} {
+		c.Get(k)
+	}
+	if stats := c.PrefetchStats(); stats.Hits.Load() != 2 || stats.Accuracy() != 0.5 {
+		t.Fatalf("hits %d, accuracy %v", stats.Hits.Load(), stats.Accuracy())
+	}
+	off := newCache(t, WithStore(store), WithPrefetcher(NoPrefetch()))
+	off.Get(5)
+	if off.Len() != 1 {
+		t.Fatalf("NoPrefetch loaded %v", off.Keys())
+	}
+}
+
+func TestCacheTinyLFU(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 1000; i++ {
+		store.Put(i, i)
+	}
+	c := newCache(t, WithStore(store), WithPrefetcher(NoPrefetch()), WithOldCapacity(4), WithYoungCapacity(4), WithTinyLFU())
+	for round := 0; round < 4; round++ {
+		for k := 0; k < 4; k++ {
+			c.Get(k)
+		}
+	}
+	// 只访问一次的 key 不能替换老年区中的热点数据
+	for k := 100; k < 110; k++ {
+		if v, err := c.Get(k); err != nil || v != k {
+			t.Fatalf("Get(%d) = (%v, %v)", k, v, err)
+		}
+	}
+	if got := fmt.Sprint(c.Keys()[:4]); got != "[3 2 1 0]" {
+		t.Fatalf("old zone = %s, want the hot keys", got)
+	}
+}
+
+func TestCacheAdaptiveSplit(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 100; i++ {
+		store.Put(i, i)
+	}
+	m := metrics.NewCollector("", nil)
+	c := newCache(t, WithStore(store), WithPrefetcher(NoPrefetch()), WithOldCapacity(4), WithYoungCapacity(4),
+		WithAdaptiveSplit(2, 6), WithMetrics(m))
+	for round := 0; round < 5; round++ {
+		for k := 0; k < 10; k++ {
+			c.Get(k)
+		}
+	}
+	s := c.SplitStats()
+	if !s.Adaptive || s.OldCapacity != 6 || s.YoungCapacity != 2 || s.OldGhostHits == 0 {
+		t.Fatalf("split = %+v", s)
+	}
+	if z := m.Snapshot().Zones; z[0].Capacity != 6 || z[1].Capacity != 2 {
+		t.Fatalf("metrics zones = %+v", z)
+	}
+}
+
+func TestCacheWriteBack(t *testing.T) {
+	store := NewMemoryStore()
+	c := newCache(t, WithStore(store), WithWriteMode(WriteBack), WithFlushInterval(time.Hour))
+	if err := c.Put("a", 1); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if len(c.Dirty()) != 1 {
+		t.Fatalf("Dirty() = %v", c.Dirty())
+	}
+	if err := c.Close(); err != nil || len(c.Dirty()) != 0 {
+		t.Fatalf("Close = %v, dirty %v", err, c.Dirty())
+	}
+	if v, err := store.Load("a"); err != nil || v != 1 {
+		t.Fatalf("store has (%v, %v) after Close", v, err)
+	}
+	if err := newCache(t, WithWriteMode(WriteThrough)).Put("a", 1); !errors.Is(err, ErrReadOnlyStore) {
+		t.Fatalf("Put without a store = %v, want ErrReadOnlyStore", err)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	store := NewMemoryStore(Item{Key: "a", Value: 1})
+	c := newCache(t, WithStore(store), WithTTL(time.Hour), WithSweepInterval(time.Millisecond))
+	defer c.Close()
+	c.Get("a")
+	c.PutWithTTL("b", 2, time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for c.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expired entry was not swept: %v", c.Keys())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !c.Contains("a") {
+		t.Fatalf("a expired before its TTL")
+	}
+}
+
+func TestCacheHooksAndMetrics(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 10; i++ {
+		store.Put(i, i)
+	}
+	var mu sync.Mutex
+	var events []string
+	m := metrics.NewCollector("", nil)
+	c := newCache(t, WithStore(store), WithOldCapacity(1), WithYoungCapacity(2),
+		WithPrefetcher(FixedPrefetch(1)), WithMetrics(m),
+		WithHooks(Hooks{
+			OnEvict: func(key interface{}, from, to Zone) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, fmt.Sprintf("%v:%v->%v", key, from, to))
+			},
+			OnPromote: func(key interface{}) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, fmt.Sprintf("%v:promote", key))
+			},
+		}))
+
+	c.Get(5) // 未命中，加载 5，预读 4 6
+	c.Get(5) // 老年区命中
+	c.Get(6) // 青年区命中，晋升；5 降级到青年区
+	c.Get(9) // 未命中，6 降级，青年区淘汰 4；预读 8，青年区淘汰 5
+	if got := fmt.Sprint(events); got != "[5:old->young 6:promote 6:old->young 4:young->none 5:young->none]" {
+		t.Fatalf("events %s", got)
+	}
+
+	s := m.Snapshot()
+	if s.OldHits != 1 || s.YoungHits != 1 || s.Misses != 2 || s.Promotions != 1 || s.Demotions != 2 || s.Evictions != 2 {
+		t.Fatalf("snapshot %+v", s)
+	}
+	if s.Prefetched != 3 || s.PrefetchHits != 1 || s.LoadLatency.Count != 2 {
+		t.Fatalf("prefetched %d, prefetch hits %d, loads %d", s.Prefetched, s.PrefetchHits, s.LoadLatency.Count)
+	}
+	if len(s.Zones) != 2 || s.Zones[0].Len != 1 || s.Zones[1].Len != 2 || s.Zones[1].Capacity != 2 {
+		t.Fatalf("zones %+v", s.Zones)
+	}
+}
+
+// TestCacheConcurrentGetPut Get 和 Put、Delete 并发执行时只依靠内部缓存的锁，不能有数据竞争
+func TestCacheConcurrentGetPut(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 50; i++ {
+		store.Put(i, i)
+	}
+	c := newCache(t, WithStore(store), WithOldCapacity(8), WithYoungCapacity(8), WithTTL(time.Millisecond), WithRefreshAhead())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 300; i++ {
+				k := (w + i) % 50
+				switch i % 3 {
+				case 0:
+					c.Put(k, k)
+				case 1:
+					if v, err := c.GetContext(ctx, k); err != nil || v != k {
+						t.Errorf("Get(%d) = (%v, %v)", k, v, err)
+						return
+					}
+				default:
+					c.Delete(k)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// TestCachePolicies 每种淘汰策略都满足相同的基本语义
+func TestCachePolicies(t *testing.T) {
+	for _, p := range []EvictionPolicy{PolicyTwoZone, PolicyLFU, PolicyARC} {
+		t.Run(p.String(), func(t *testing.T) {
+			store := NewMemoryStore()
+			for i := 0; i < 100; i++ {
+				store.Put(i, i*i)
+			}
+			c := newCache(t, WithPolicy(p), WithStore(store), WithPrefetcher(NoPrefetch()), WithOldCapacity(4), WithYoungCapacity(4))
+			for i := 0; i < 100; i++ {
+				if v, err := c.Get(i); err != nil || v != i*i {
+					t.Fatalf("Get(%d) = (%v, %v)", i, v, err)
+				}
+				if c.Len() > 8 {
+					t.Fatalf("Len() = %d exceeds the capacity", c.Len())
+				}
+			}
+			if _, err := c.Get(-1); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get(-1) error = %v, want ErrNotFound", err)
+			}
+			if err := c.Put("k", "v"); err != nil {
+				t.Fatal(err)
+			}
+			if v, ok := c.Peek("k"); !ok || v != "v" {
+				t.Fatalf("Peek(k) = (%v, %v)", v, ok)
+			}
+			if !c.Delete("k") || c.Contains("k") {
+				t.Fatal("Delete(k) did not remove the key")
+			}
+			if err := c.Close(); err != nil {
+				t.Fatal(err)
+			}
+			c.Purge()
+			if c.Len() != 0 || len(c.Keys()) != 0 {
+				t.Fatalf("Purge left %v", c.Keys())
+			}
+		})
+	}
+}
+
+// TestCacheUnsupportedOptions 其他淘汰策略不支持的配置项不会被静默忽略
+func TestCacheUnsupportedOptions(t *testing.T) {
+	store := NewMemoryStore()
+	for _, opt := range []Option{WithTinyLFU(), WithAdaptiveSplit(0, 0)} {
+		for _, p := range []EvictionPolicy{PolicyLFU, PolicyARC} {
+			if c, err := New(WithPolicy(p), WithStore(store), opt); !errors.Is(err, ErrUnsupported) || c != nil {
+				t.Fatalf("New with policy %v = (%v, %v), want ErrUnsupported", p, c, err)
+			}
+		}
+		if _, err := New(WithStore(store), opt); err != nil {
+			t.Fatalf("two-zone cache rejected an option: %v", err)
+		}
+	}
+}
+
+// TestCachePolicyFeatures 其他淘汰策略同样支持写回、存活时间、预读和监控
+func TestCachePolicyFeatures(t *testing.T) {
+	for _, p := range []EvictionPolicy{PolicyLFU, PolicyARC} {
+		t.Run(p.String(), func(t *testing.T) {
+			store := NewMemoryStore()
+			for i := 0; i < 100; i++ {
+				store.Put(i, i)
+			}
+			m := metrics.NewCollector("", nil)
+			c := newCache(t, WithPolicy(p), WithStore(store), WithOldCapacity(2), WithYoungCapacity(2),
+				WithPrefetcher(FixedPrefetch(1)), WithWriteMode(WriteBack), WithTTL(time.Millisecond), WithMetrics(m))
+
+			if err := c.Put("dirty", 1); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 100; i += 10 {
+				c.Get(i)
+			}
+			if v, _ := store.Load("dirty"); v != 1 {
+				t.Fatalf("evicted dirty value was not written back, store has %v", v)
+			}
+			// 从后端存储加载的项按默认的存活时间过期，脏数据不过期
+			time.Sleep(2 * time.Millisecond)
+			if c.Contains(90) || !c.Contains("dirty") && len(c.Dirty()) != 0 {
+				t.Fatalf("expired entry is still cached, keys = %v", c.Keys())
+			}
+
+			s := m.Snapshot()
+			if s.Misses != 10 || s.Evictions == 0 || s.Prefetched == 0 {
+				t.Fatalf("snapshot %+v", s)
+			}
+			if len(s.Zones) != 2 || s.Zones[0].Len != c.Len() || s.Zones[0].Capacity != 4 || s.Zones[1].Capacity != 0 {
+				t.Fatalf("zones %+v", s.Zones)
+			}
+			if err := c.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
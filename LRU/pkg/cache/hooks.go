@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"time"
+
+	internal "bash_algorithm/LRU/internal/cache"
+	"bash_algorithm/LRU/pkg/metrics"
+)
+
+type (
+	// Zone 缓存中的区域
+	Zone = internal.Zone
+	// Hooks 缓存事件的回调，回调必须并发安全并且不能再访问缓存
+	Hooks = internal.Hooks
+)
+
+const (
+	// ZoneNone 不在缓存中
+	ZoneNone = internal.ZoneNone
+	// ZoneOld 老年区
+	ZoneOld = internal.ZoneOld
+	// ZoneYoung 青年区
+	ZoneYoung = internal.ZoneYoung
+)
+
+// WithHooks 添加事件回调，可以多次使用，回调按添加的顺序调用
+func WithHooks(h Hooks) Option {
+	return func(o *options) { o.hooks = append(o.hooks, h) }
+}
+
+// WithMetrics 把缓存事件和各区的占用报告给 m
+func WithMetrics(m *metrics.Collector) Option {
+	return func(o *options) { o.metrics = m }
+}
+
+// metricsHooks 把缓存事件转发给 m
+func metricsHooks(m *metrics.Collector) Hooks {
+	return Hooks{
+		OnAccess:   func(_ interface{}, zone Zone) { m.Access(zone.String()) },
+		OnEvict:    func(_ interface{}, from, to Zone) { m.Evict(from.String(), to.String()) },
+		OnPromote:  func(interface{}) { m.Promote() },
+		OnPrefetch: func(_ interface{}, hit bool) { m.Prefetch(hit) },
+		OnLoad:     func(_ interface{}, latency time.Duration, err error) { m.Load(latency, err) },
+	}
+}
+
+// occupancy 返回查询 c 各区占用的函数，只使用缓存内部的锁，可以和缓存的其他操作并发调用。
+// 两区策略以外的策略只有老年区，青年区的数量和容量为 0
+func occupancy(c *internal.LRUCache) func() []metrics.ZoneOccupancy {
+	return func() []metrics.ZoneOccupancy {
+		return []metrics.ZoneOccupancy{
+			{Zone: metrics.ZoneOld, Len: c.ZoneLen(internal.ZoneOld), Capacity: c.ZoneCapacity(internal.ZoneOld)},
+			{Zone: metrics.ZoneYoung, Len: c.ZoneLen(internal.ZoneYoung), Capacity: c.ZoneCapacity(internal.ZoneYoung)},
+		}
+	}
+}
+
+// combineHooks 把多组回调合并为一组，依次调用
+func combineHooks(hooks []Hooks) Hooks {
+	if len(hooks) == 1 {
+		return hooks[0]
+	}
+	var h Hooks
+	for _, x := range hooks {
+		x := x
+		if f := x.OnAccess; f != nil {
+			prev := h.OnAccess
+			h.OnAccess = func(key interface{}, zone Zone) {
+				if prev != nil {
+					prev(key, zone)
+				}
+				f(key, zone)
+			}
+		}
+		if f := x.OnEvict; f != nil {
+			prev := h.OnEvict
+			h.OnEvict = func(key interface{}, from, to Zone) {
+				if prev != nil {
+					prev(key, from, to)
+				}
+				f(key, from, to)
+			}
+		}
+		if f := x.OnPromote; f != nil {
+			prev := h.OnPromote
+			h.OnPromote = func(key interface{}) {
+				if prev != nil {
+					prev(key)
+				}
+				f(key)
+			}
+		}
+		if f := x.OnPrefetch; f != nil {
+			prev := h.OnPrefetch
+			h.OnPrefetch = func(key interface{}, hit bool) {
+				if prev != nil {
+					prev(key, hit)
+				}
+				f(key, hit)
+			}
+		}
+		if f := x.OnLoad; f != nil {
+			prev := h.OnLoad
+			h.OnLoad = func(key interface{}, latency time.Duration, err error) {
+				if prev != nil {
+					prev(key, latency, err)
+				}
+				f(key, latency, err)
+			}
+		}
+	}
+	return h
+}
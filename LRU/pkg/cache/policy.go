@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"errors"
+
+	internal "bash_algorithm/LRU/internal/cache"
+)
+
+// ErrUnsupported 使用 PolicyTwoZone 以外的淘汰策略时设置了只有两区策略支持的功能
+var ErrUnsupported = errors.New("cache: not supported by the eviction policy")
+
+// EvictionPolicy 缓存满了之后选择淘汰对象的策略
+type EvictionPolicy = internal.EvictionPolicy
+
+const (
+	// PolicyTwoZone 老年区/青年区两区 LRU，默认策略
+	PolicyTwoZone = internal.PolicyTwoZone
+	// PolicyLFU 淘汰访问次数最少的项，次数相同时淘汰最久没有访问的
+	PolicyLFU = internal.PolicyLFU
+	// PolicyARC 自适应替换缓存，根据最近被淘汰的 key 的再次访问调整最近访问和频繁访问两部分的大小
+	PolicyARC = internal.PolicyARC
+)
+
+// WithPolicy 设置淘汰策略，默认为 PolicyTwoZone。其他策略只有一个区，容量为老年区和青年区容量之和，
+// 预读的项和加载的项一样交给策略；准入过滤和自适应划分依赖两个区，同时设置时 New 返回 ErrUnsupported
+func WithPolicy(p EvictionPolicy) Option {
+	return func(o *options) { o.policy = p }
+}
+
+// twoZoneOnly 返回已经设置的、只有两区策略支持的第一个配置项，没有时返回空字符串
+func (o *options) twoZoneOnly() string {
+	switch {
+	case o.admission:
+		return "WithTinyLFU"
+	case o.adaptive:
+		return "WithAdaptiveSplit"
+	}
+	return ""
+}
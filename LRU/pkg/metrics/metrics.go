@@ -0,0 +1,298 @@
+// Package metrics 两区 LRU 缓存的性能监控：各区的命中率、淘汰和晋升次数、预读效果、
+// 加载耗时直方图以及各区的占用，提供快照接口和 Prometheus 文本格式输出
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 区的名字
+const (
+	ZoneOld   = "old"
+	ZoneYoung = "young"
+	ZoneNone  = "none" // 不在缓存中
+)
+
+// DefaultNamespace Prometheus 指标名的默认前缀
+const DefaultNamespace = "lru_cache"
+
+// DefaultLatencyBuckets 加载耗时直方图默认的桶上界，单位为秒
+var DefaultLatencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// ZoneOccupancy 一个区的占用
+type ZoneOccupancy struct {
+	Zone     string
+	Len      int
+	Capacity int
+}
+
+// Collector 收集缓存事件，所有方法都可以并发调用
+type Collector struct {
+	namespace string
+
+	oldHits, youngHits, misses atomic.Int64
+	demotions, evictions       atomic.Int64 // 老年区降级到青年区、淘汰出青年区
+	promotions                 atomic.Int64
+	prefetched, prefetchHits   atomic.Int64
+	loadErrors                 atomic.Int64
+	latency                    *histogram
+
+	mu        sync.RWMutex
+	occupancy func() []ZoneOccupancy
+}
+
+// NewCollector 创建 Collector，namespace 为空时使用 DefaultNamespace，buckets 为 nil 时使用 DefaultLatencyBuckets
+func NewCollector(namespace string, buckets []float64) *Collector {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+	return &Collector{namespace: namespace, latency: newHistogram(buckets)}
+}
+
+// Access 记录一次访问，zone 为命中的区，未命中时为 ZoneNone
+func (c *Collector) Access(zone string) {
+	switch zone {
+	case ZoneOld:
+		c.oldHits.Add(1)
+	case ZoneYoung:
+		c.youngHits.Add(1)
+	default:
+		c.misses.Add(1)
+	}
+}
+
+// Evict 记录一次淘汰：to 为 ZoneNone 时是淘汰出缓存（只有一个区的策略从老年区淘汰），否则是从老年区降级到青年区
+func (c *Collector) Evict(from, to string) {
+	if to != ZoneNone {
+		c.demotions.Add(1)
+	} else {
+		c.evictions.Add(1)
+	}
+}
+
+// Promote 记录一次从青年区到老年区的晋升
+func (c *Collector) Promote() {
+	c.promotions.Add(1)
+}
+
+// Prefetch 记录预读：hit 为 false 表示预读了一个项，为 true 表示预读的项被访问到
+func (c *Collector) Prefetch(hit bool) {
+	if hit {
+		c.prefetchHits.Add(1)
+	} else {
+		c.prefetched.Add(1)
+	}
+}
+
+// Load 记录一次从后端存储的加载
+func (c *Collector) Load(latency time.Duration, err error) {
+	c.latency.observe(latency.Seconds())
+	if err != nil {
+		c.loadErrors.Add(1)
+	}
+}
+
+// SetOccupancy 设置查询各区占用的函数，在生成快照时调用
+func (c *Collector) SetOccupancy(f func() []ZoneOccupancy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.occupancy = f
+}
+
+// Bucket 直方图的一个桶，Count 为耗时小于等于 UpperBound 的累计次数
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+// HistogramSnapshot 直方图的快照，Buckets 的最后一个桶上界为 +Inf
+type HistogramSnapshot struct {
+	Buckets []Bucket
+	Sum     float64 // 秒
+	Count   uint64
+}
+
+// Snapshot 某一时刻的指标，各计数器分别读取，彼此之间不保证完全一致
+type Snapshot struct {
+	OldHits, YoungHits, Misses int64
+	// HitRatio 总命中率，OldHitRatio 和 YoungHitRatio 为各区命中占所有访问的比例
+	HitRatio, OldHitRatio, YoungHitRatio, MissRatio float64
+
+	Demotions, Evictions, Promotions int64
+
+	Prefetched, PrefetchHits int64
+	PrefetchAccuracy         float64 // 预读的项中被访问到的比例
+
+	LoadErrors  int64
+	LoadLatency HistogramSnapshot
+
+	Zones []ZoneOccupancy
+}
+
+// Snapshot 返回当前的指标
+func (c *Collector) Snapshot() Snapshot {
+	s := Snapshot{
+		OldHits:      c.oldHits.Load(),
+		YoungHits:    c.youngHits.Load(),
+		Misses:       c.misses.Load(),
+		Demotions:    c.demotions.Load(),
+		Evictions:    c.evictions.Load(),
+		Promotions:   c.promotions.Load(),
+		Prefetched:   c.prefetched.Load(),
+		PrefetchHits: c.prefetchHits.Load(),
+		LoadErrors:   c.loadErrors.Load(),
+		LoadLatency:  c.latency.snapshot(),
+	}
+	total := s.OldHits + s.YoungHits + s.Misses
+	s.OldHitRatio = ratio(s.OldHits, total)
+	s.YoungHitRatio = ratio(s.YoungHits, total)
+	s.HitRatio = ratio(s.OldHits+s.YoungHits, total)
+	s.MissRatio = ratio(s.Misses, total)
+	s.PrefetchAccuracy = ratio(s.PrefetchHits, s.Prefetched)
+
+	c.mu.RLock()
+	occupancy := c.occupancy
+	c.mu.RUnlock()
+	if occupancy != nil {
+		s.Zones = occupancy()
+	}
+	return s
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出当前的指标
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	s := c.Snapshot()
+	p := &promWriter{namespace: c.namespace}
+
+	p.family("hits_total", "counter", "Cache hits by zone.")
+	p.sample("hits_total", `zone="old"`, float64(s.OldHits))
+	p.sample("hits_total", `zone="young"`, float64(s.YoungHits))
+	p.family("misses_total", "counter", "Cache misses.")
+	p.sample("misses_total", "", float64(s.Misses))
+	p.family("hit_ratio", "gauge", "Share of accesses served by each zone.")
+	p.sample("hit_ratio", `zone="old"`, s.OldHitRatio)
+	p.sample("hit_ratio", `zone="young"`, s.YoungHitRatio)
+
+	p.family("evictions_total", "counter", "Entries evicted from a zone.")
+	p.sample("evictions_total", `from="old",to="young"`, float64(s.Demotions))
+	p.sample("evictions_total", `from="young",to="none"`, float64(s.Evictions))
+	p.family("promotions_total", "counter", "Entries promoted from the young zone to the old zone.")
+	p.sample("promotions_total", "", float64(s.Promotions))
+
+	p.family("prefetched_total", "counter", "Entries prefetched into the young zone.")
+	p.sample("prefetched_total", "", float64(s.Prefetched))
+	p.family("prefetch_hits_total", "counter", "Prefetched entries that were accessed.")
+	p.sample("prefetch_hits_total", "", float64(s.PrefetchHits))
+
+	p.family("load_errors_total", "counter", "Failed loads from the backing store.")
+	p.sample("load_errors_total", "", float64(s.LoadErrors))
+	p.family("load_duration_seconds", "histogram", "Latency of loads from the backing store.")
+	for _, b := range s.LoadLatency.Buckets {
+		p.sample("load_duration_seconds_bucket", `le="`+formatFloat(b.UpperBound)+`"`, float64(b.Count))
+	}
+	p.sample("load_duration_seconds_sum", "", s.LoadLatency.Sum)
+	p.sample("load_duration_seconds_count", "", float64(s.LoadLatency.Count))
+
+	if len(s.Zones) > 0 {
+		p.family("entries", "gauge", "Entries currently held by each zone.")
+		for _, z := range s.Zones {
+			p.sample("entries", `zone="`+z.Zone+`"`, float64(z.Len))
+		}
+		p.family("capacity", "gauge", "Capacity of each zone.")
+		for _, z := range s.Zones {
+			p.sample("capacity", `zone="`+z.Zone+`"`, float64(z.Capacity))
+		}
+	}
+	_, err := io.WriteString(w, p.String())
+	return err
+}
+
+// ServeHTTP 以 Prometheus 文本格式响应，Collector 可以直接挂到 /metrics
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WritePrometheus(w)
+}
+
+// promWriter 拼接 Prometheus 文本格式
+type promWriter struct {
+	strings.Builder
+	namespace string
+}
+
+func (p *promWriter) family(name, typ, help string) {
+	fmt.Fprintf(p, "# HELP %s_%s %s\n# TYPE %s_%s %s\n", p.namespace, name, help, p.namespace, name, typ)
+}
+
+func (p *promWriter) sample(name, labels string, value float64) {
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(p, "%s_%s%s %s\n", p.namespace, name, labels, formatFloat(value))
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func ratio(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// histogram 固定桶的直方图，counts[i] 为落在第 i 个桶（不累计）的次数，最后一个桶为 +Inf
+type histogram struct {
+	bounds []float64
+	counts []atomic.Uint64
+	sum    atomic.Uint64 // float64 的位模式
+	count  atomic.Uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	bounds = append([]float64(nil), bounds...)
+	sort.Float64s(bounds)
+	return &histogram{bounds: bounds, counts: make([]atomic.Uint64, len(bounds)+1)}
+}
+
+func (h *histogram) observe(v float64) {
+	h.counts[sort.SearchFloat64s(h.bounds, v)].Add(1)
+	h.count.Add(1)
+	for {
+		old := h.sum.Load()
+		if h.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (h *histogram) snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{Buckets: make([]Bucket, len(h.counts))}
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		bound := math.Inf(1)
+		if i < len(h.bounds) {
+			bound = h.bounds[i]
+		}
+		s.Buckets[i] = Bucket{UpperBound: bound, Count: cumulative}
+	}
+	s.Sum = math.Float64frombits(h.sum.Load())
+	s.Count = h.count.Load()
+	return s
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectorSnapshot(t *testing.T) {
+	c := NewCollector("", []float64{0.001, 0.01})
+	for i := 0; i < 6; i++ {
+		c.Access(ZoneOld)
+	}
+	c.Access(ZoneYoung)
+	c.Access(ZoneYoung)
+	c.Access(ZoneNone)
+	c.Access(ZoneNone)
+	c.Evict(ZoneOld, ZoneYoung)
+	c.Evict(ZoneYoung, ZoneNone)
+	c.Evict(ZoneOld, ZoneNone)
+	c.Promote()
+	for i := 0; i < 4; i++ {
+		c.Prefetch(false)
+	}
+	c.Prefetch(true)
+	c.Load(500*time.Microsecond, nil)
+	c.Load(5*time.Millisecond, nil)
+	c.Load(time.Second, errors.New("io"))
+	c.SetOccupancy(func() []ZoneOccupancy {
+		return []ZoneOccupancy{{Zone: ZoneOld, Len: 3, Capacity: 4}}
+	})
+
+	s := c.Snapshot()
+	if s.HitRatio != 0.8 || s.OldHitRatio != 0.6 || s.YoungHitRatio != 0.2 || s.MissRatio != 0.2 {
+		t.Fatalf("ratios %v %v %v %v", s.HitRatio, s.OldHitRatio, s.YoungHitRatio, s.MissRatio)
+	}
+	if s.Demotions != 1 || s.Evictions != 2 || s.Promotions != 1 {
+		t.Fatalf("demotions %d, evictions %d, promotions %d", s.Demotions, s.Evictions, s.Promotions)
+	}
+	if s.PrefetchAccuracy != 0.25 || s.LoadErrors != 1 {
+		t.Fatalf("prefetch accuracy %v, load errors %d", s.PrefetchAccuracy, s.LoadErrors)
+	}
+	h := s.LoadLatency
+	if h.Count != 3 || len(h.Buckets) != 3 || h.Buckets[0].Count != 1 || h.Buckets[1].Count != 2 || h.Buckets[2].Count != 3 {
+		t.Fatalf("histogram %+v", h)
+	}
+	if h.Sum < 1.005 || h.Sum > 1.0056 {
+		t.Fatalf("histogram sum %v", h.Sum)
+	}
+	if len(s.Zones) != 1 || s.Zones[0].Len != 3 {
+		t.Fatalf("zones %+v", s.Zones)
+	}
+}
+
+func TestCollectorPrometheus(t *testing.T) {
+	c := NewCollector("test", []float64{0.5})
+	c.Access(ZoneOld)
+	c.Access(ZoneNone)
+	c.Load(time.Second, nil)
+	c.SetOccupancy(func() []ZoneOccupancy {
+		return []ZoneOccupancy{{Zone: ZoneOld, Len: 1, Capacity: 2}, {Zone: ZoneYoung, Len: 0, Capacity: 2}}
+	})
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE test_hits_total counter\n",
+		`test_hits_total{zone="old"} 1` + "\n",
+		"test_misses_total 1\n",
+		`test_hit_ratio{zone="old"} 0.5` + "\n",
+		`test_evictions_total{from="young",to="none"} 0` + "\n",
+		"# TYPE test_load_duration_seconds histogram\n",
+		`test_load_duration_seconds_bucket{le="0.5"} 0` + "\n",
+		`test_load_duration_seconds_bucket{le="+Inf"} 1` + "\n",
+		"test_load_duration_seconds_sum 1\n",
+		"test_load_duration_seconds_count 1\n",
+		`test_entries{zone="old"} 1` + "\n",
+		`test_capacity{zone="young"} 2` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+}
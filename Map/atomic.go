@@ -0,0 +1,198 @@
+package Map
+
+// 本文件为 MutexMap 和 SharedMap 提供原子的复合操作（读-改-写），
+// 避免调用方在 Get 和 Put 之间产生竞态。MutexMap 在全局锁下执行，
+// SharedMap 只锁 key 所在的分片。
+//
+// CompareAndSwap 和 CompareAndDelete 与 sync.Map 一样使用 == 比较值，
+// 当值的动态类型不可比较时会 panic。
+
+// LoadOrStore key 存在时返回已有的值和 true，否则写入 value 并返回 value 和 false
+func (m *MutexMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	m.Lock()
+	defer m.Unlock()
+	return loadOrStore(m.v, &m.watchers, key, value)
+}
+
+// LoadAndDelete 删除 key 并返回删除前的值，loaded 表示 key 是否存在
+func (m *MutexMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	m.Lock()
+	defer m.Unlock()
+	return loadAndDelete(m.v, &m.watchers, key)
+}
+
+// CompareAndSwap 仅当 key 存在且当前值等于 old 时将其替换为 new
+func (m *MutexMap[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	m.Lock()
+	defer m.Unlock()
+	return compareAndSwap(m.v, &m.watchers, key, old, new)
+}
+
+// CompareAndDelete 仅当 key 存在且当前值等于 old 时删除 key
+func (m *MutexMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	m.Lock()
+	defer m.Unlock()
+	return compareAndDelete(m.v, &m.watchers, key, old)
+}
+
+// Compute 在锁内以 key 的当前值调用 fn，fn 返回新值以及是否保留，
+// keep 为 false 时删除 key。返回计算后的值以及 key 是否存在
+func (m *MutexMap[K, V]) Compute(key K, fn func(old V, loaded bool) (newValue V, keep bool)) (V, bool) {
+	m.Lock()
+	defer m.Unlock()
+	return compute(m.v, &m.watchers, key, fn)
+}
+
+// Update 仅当 key 存在时在锁内用 fn 的返回值替换当前值，返回新值以及 key 是否存在
+func (m *MutexMap[K, V]) Update(key K, fn func(old V) V) (V, bool) {
+	m.Lock()
+	defer m.Unlock()
+	return update(m.v, &m.watchers, key, fn)
+}
+
+// GetOrCompute key 存在时返回已有的值，否则在锁内调用 fn 构造值并写入，
+// fn 对每个 key 最多只会执行一次。computed 表示本次调用是否执行了 fn
+func (m *MutexMap[K, V]) GetOrCompute(key K, fn func() V) (actual V, computed bool) {
+	m.RLock()
+	v, ok := m.v[key]
+	m.RUnlock()
+	if ok {
+		return v, false
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	return getOrCompute(m.v, &m.watchers, key, fn)
+}
+
+// LoadAndDelete 删除 key 并返回删除前的值，loaded 表示 key 是否存在
+func (m *SharedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	s := m.lockShard(key, true)
+	defer s.Unlock()
+	return loadAndDelete(s.items, &m.watchers, key)
+}
+
+// CompareAndSwap 仅当 key 存在且当前值等于 old 时将其替换为 new
+func (m *SharedMap[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	s := m.lockShard(key, true)
+	defer s.Unlock()
+	return compareAndSwap(s.items, &m.watchers, key, old, new)
+}
+
+// CompareAndDelete 仅当 key 存在且当前值等于 old 时删除 key
+func (m *SharedMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	s := m.lockShard(key, true)
+	defer s.Unlock()
+	return compareAndDelete(s.items, &m.watchers, key, old)
+}
+
+// Compute 在分片锁内以 key 的当前值调用 fn，fn 返回新值以及是否保留，
+// keep 为 false 时删除 key。返回计算后的值以及 key 是否存在
+func (m *SharedMap[K, V]) Compute(key K, fn func(old V, loaded bool) (newValue V, keep bool)) (V, bool) {
+	s := m.lockShard(key, true)
+	defer s.Unlock()
+	return compute(s.items, &m.watchers, key, fn)
+}
+
+// Update 仅当 key 存在时在分片锁内用 fn 的返回值替换当前值，返回新值以及 key 是否存在
+func (m *SharedMap[K, V]) Update(key K, fn func(old V) V) (V, bool) {
+	s := m.lockShard(key, true)
+	defer s.Unlock()
+	return update(s.items, &m.watchers, key, fn)
+}
+
+// GetOrCompute key 存在时返回已有的值，否则在分片锁内调用 fn 构造值并写入，
+// fn 对每个 key 最多只会执行一次。computed 表示本次调用是否执行了 fn
+func (m *SharedMap[K, V]) GetOrCompute(key K, fn func() V) (actual V, computed bool) {
+	s := m.lockShard(key, false)
+	v, ok := s.items[key]
+	s.RUnlock()
+	if ok {
+		return v, false
+	}
+
+	s = m.lockShard(key, true)
+	defer s.Unlock()
+	return getOrCompute(s.items, &m.watchers, key, fn)
+}
+
+// 以下函数是复合操作的具体实现，调用方需要持有 items 对应的写锁，
+// 修改发生时在锁内通知 w 上的订阅者
+
+func loadOrStore[K comparable, V any](items map[K]V, w *watchHub[K, V], key K, value V) (V, bool) {
+	if v, ok := items[key]; ok {
+		return v, true
+	}
+	items[key] = value
+	var zero V
+	w.put(key, zero, false, value)
+	return value, false
+}
+
+func loadAndDelete[K comparable, V any](items map[K]V, w *watchHub[K, V], key K) (V, bool) {
+	v, ok := items[key]
+	if ok {
+		delete(items, key)
+		w.delete(key, v)
+	}
+	return v, ok
+}
+
+func compareAndSwap[K comparable, V any](items map[K]V, w *watchHub[K, V], key K, old, new V) bool {
+	v, ok := items[key]
+	if !ok || any(v) != any(old) {
+		return false
+	}
+	items[key] = new
+	w.put(key, v, true, new)
+	return true
+}
+
+func compareAndDelete[K comparable, V any](items map[K]V, w *watchHub[K, V], key K, old V) bool {
+	v, ok := items[key]
+	if !ok || any(v) != any(old) {
+		return false
+	}
+	delete(items, key)
+	w.delete(key, v)
+	return true
+}
+
+func compute[K comparable, V any](items map[K]V, w *watchHub[K, V], key K, fn func(old V, loaded bool) (V, bool)) (V, bool) {
+	old, loaded := items[key]
+	v, keep := fn(old, loaded)
+	if !keep {
+		if loaded {
+			delete(items, key)
+			w.delete(key, old)
+		}
+		var zero V
+		return zero, false
+	}
+	items[key] = v
+	w.put(key, old, loaded, v)
+	return v, true
+}
+
+func update[K comparable, V any](items map[K]V, w *watchHub[K, V], key K, fn func(old V) V) (V, bool) {
+	old, ok := items[key]
+	if !ok {
+		return old, false
+	}
+	v := fn(old)
+	items[key] = v
+	w.put(key, old, true, v)
+	return v, true
+}
+
+func getOrCompute[K comparable, V any](items map[K]V, w *watchHub[K, V], key K, fn func() V) (V, bool) {
+	// 获取写锁前可能已经有其他协程写入，需要再检查一次
+	if v, ok := items[key]; ok {
+		return v, false
+	}
+	v := fn()
+	items[key] = v
+	var zero V
+	w.put(key, zero, false, v)
+	return v, true
+}
@@ -0,0 +1,129 @@
+package Map
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// compoundMap MutexMap 和 SharedMap 共同的复合操作，方便用同一组用例测试
+type compoundMap interface {
+	Put(key string, value int)
+	Get(key string) (int, bool)
+	LoadOrStore(key string, value int) (int, bool)
+	LoadAndDelete(key string) (int, bool)
+	CompareAndSwap(key string, old, new int) bool
+	CompareAndDelete(key string, old int) bool
+	Compute(key string, fn func(old int, loaded bool) (int, bool)) (int, bool)
+	Update(key string, fn func(old int) int) (int, bool)
+	GetOrCompute(key string, fn func() int) (int, bool)
+}
+
+func compoundMaps() map[string]func() compoundMap {
+	return map[string]func() compoundMap{
+		"MutexMap":  func() compoundMap { return NewMutexMap[string, int]() },
+		"SharedMap": func() compoundMap { return NewSharedMap[string, int](4, nil) },
+	}
+}
+
+func TestCompoundOps(t *testing.T) {
+	for name, newMap := range compoundMaps() {
+		t.Run(name, func(t *testing.T) {
+			m := newMap()
+			if v, loaded := m.LoadOrStore("a", 1); loaded || v != 1 {
+				t.Fatalf("LoadOrStore new = (%v, %v)", v, loaded)
+			}
+			if v, loaded := m.LoadOrStore("a", 2); !loaded || v != 1 {
+				t.Fatalf("LoadOrStore existing = (%v, %v)", v, loaded)
+			}
+
+			if m.CompareAndSwap("a", 5, 6) {
+				t.Fatalf("CompareAndSwap succeeded with a stale old value")
+			}
+			if m.CompareAndSwap("missing", 0, 1) {
+				t.Fatalf("CompareAndSwap succeeded on a missing key")
+			}
+			if !m.CompareAndSwap("a", 1, 3) {
+				t.Fatalf("CompareAndSwap failed with the current value")
+			}
+			if m.CompareAndDelete("a", 1) || !m.CompareAndDelete("a", 3) {
+				t.Fatalf("CompareAndDelete did not compare the current value")
+			}
+
+			if _, ok := m.Update("a", func(old int) int { return old + 1 }); ok {
+				t.Fatalf("Update created a missing key")
+			}
+			m.Put("a", 10)
+			if v, ok := m.Update("a", func(old int) int { return old + 1 }); !ok || v != 11 {
+				t.Fatalf("Update = (%v, %v), want 11", v, ok)
+			}
+
+			if v, ok := m.Compute("a", func(old int, loaded bool) (int, bool) { return old * 2, loaded }); !ok || v != 22 {
+				t.Fatalf("Compute = (%v, %v), want 22", v, ok)
+			}
+			if _, ok := m.Compute("a", func(int, bool) (int, bool) { return 0, false }); ok {
+				t.Fatalf("Compute with keep=false left the key in place")
+			}
+			if _, ok := m.Get("a"); ok {
+				t.Fatalf("key a still present after Compute deleted it")
+			}
+
+			m.Put("b", 7)
+			if v, loaded := m.LoadAndDelete("b"); !loaded || v != 7 {
+				t.Fatalf("LoadAndDelete = (%v, %v)", v, loaded)
+			}
+			if _, loaded := m.LoadAndDelete("b"); loaded {
+				t.Fatalf("LoadAndDelete reported a deleted key")
+			}
+
+			if v, computed := m.GetOrCompute("c", func() int { return 42 }); !computed || v != 42 {
+				t.Fatalf("GetOrCompute new = (%v, %v)", v, computed)
+			}
+			if v, computed := m.GetOrCompute("c", func() int { panic("constructor must not run") }); computed || v != 42 {
+				t.Fatalf("GetOrCompute existing = (%v, %v)", v, computed)
+			}
+		})
+	}
+}
+
+// TestCompoundOpsConcurrent 并发的读-改-写不能丢失更新，构造函数对每个 key 只能执行一次
+func TestCompoundOpsConcurrent(t *testing.T) {
+	for name, newMap := range compoundMaps() {
+		t.Run(name, func(t *testing.T) {
+			m := newMap()
+			var constructed int64
+			var wg sync.WaitGroup
+			for g := 0; g < 16; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 500; i++ {
+						m.Compute("counter", func(old int, _ bool) (int, bool) { return old + 1, true })
+						for {
+							old, _ := m.Get("cas")
+							if m.CompareAndSwap("cas", old, old+1) {
+								break
+							}
+							m.LoadOrStore("cas", 0)
+						}
+						m.GetOrCompute("lazy", func() int {
+							atomic.AddInt64(&constructed, 1)
+							return 1
+						})
+					}
+				}()
+			}
+			wg.Wait()
+
+			if v, _ := m.Get("counter"); v != 16*500 {
+				t.Fatalf("counter = %d, want %d", v, 16*500)
+			}
+			if v, _ := m.Get("cas"); v != 16*500 {
+				t.Fatalf("cas = %d, want %d", v, 16*500)
+			}
+			if constructed != 1 {
+				t.Fatalf("constructor ran %d times, want 1", constructed)
+			}
+		})
+	}
+}
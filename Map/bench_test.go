@@ -0,0 +1,191 @@
+package Map
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"text/tabwriter"
+)
+
+// mapBenchReport 打开后 TestMapBenchmarkReport 会跑完整个基准矩阵并打印对比报告：
+//
+//	go test ./Map -run TestMapBenchmarkReport -mapbench.report
+var mapBenchReport = flag.Bool("mapbench.report", false, "run the map benchmark matrix and print a comparison report")
+
+// benchMap 参与基准测试的 map 的统一抽象
+type benchMap interface {
+	Load(key int) (int, bool)
+	Store(key, value int)
+}
+
+type mutexBenchMap struct{ m *MutexMap[int, int] }
+
+func (b mutexBenchMap) Load(key int) (int, bool) { return b.m.Get(key) }
+func (b mutexBenchMap) Store(key, value int)     { b.m.Put(key, value) }
+
+type sharedBenchMap struct{ m *SharedMap[int, int] }
+
+func (b sharedBenchMap) Load(key int) (int, bool) { return b.m.Get(key) }
+func (b sharedBenchMap) Store(key, value int)     { b.m.Put(key, value) }
+
+type lockFreeBenchMap struct{ m *LockFreeMap[int, int] }
+
+func (b lockFreeBenchMap) Load(key int) (int, bool) { return b.m.Get(key) }
+func (b lockFreeBenchMap) Store(key, value int)     { b.m.Put(key, value) }
+
+type syncBenchMap struct{ m *sync.Map }
+
+func (b syncBenchMap) Load(key int) (int, bool) {
+	v, ok := b.m.Load(key)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+func (b syncBenchMap) Store(key, value int) { b.m.Store(key, value) }
+
+// benchImpl 一种待测的 map 实现
+type benchImpl struct {
+	name string
+	new  func() benchMap
+}
+
+func benchImpls() []benchImpl {
+	return []benchImpl{
+		{"MutexMap", func() benchMap { return mutexBenchMap{NewMutexMap[int, int]()} }},
+		{"SharedMap", func() benchMap { return sharedBenchMap{NewSharedMap[int, int](DefaultShardCount, nil)} }},
+		{"LockFreeMap", func() benchMap { return lockFreeBenchMap{NewLockFreeMap[int, int](nil)} }},
+		{"sync.Map", func() benchMap { return syncBenchMap{&sync.Map{}} }},
+	}
+}
+
+// benchWorkload 一种读写负载
+type benchWorkload struct {
+	name     string
+	readPct  int  // 读操作所占的百分比
+	disjoint bool // 每个协程只访问自己独占的 key 区间
+}
+
+func benchWorkloads() []benchWorkload {
+	return []benchWorkload{
+		{name: "read-heavy", readPct: 90},
+		{name: "write-heavy", readPct: 10},
+		{name: "mixed", readPct: 50},
+		{name: "disjoint", readPct: 50, disjoint: true},
+	}
+}
+
+var (
+	benchKeySpaces = []int{1 << 10, 1 << 16}
+	benchProcs     = func() []int {
+		procs := []int{1, 4}
+		if n := runtime.NumCPU(); n > 4 {
+			procs = append(procs, n)
+		}
+		return procs
+	}()
+)
+
+// runMapBenchmark 在当前 GOMAXPROCS 下并发执行负载
+func runMapBenchmark(b *testing.B, impl benchImpl, w benchWorkload, keys int) {
+	m := impl.new()
+	for i := 0; i < keys; i++ {
+		m.Store(i, i)
+	}
+	var workerID int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := int(atomic.AddInt64(&workerID, 1))
+		r := rand.New(rand.NewSource(int64(id)))
+		base := 0
+		if w.disjoint {
+			// 独占的 key 区间放在预填充区间之后，协程之间互不重叠
+			base = id * keys
+		}
+		for pb.Next() {
+			key := base + r.Intn(keys)
+			if r.Intn(100) < w.readPct {
+				m.Load(key)
+			} else {
+				m.Store(key, key)
+			}
+		}
+	})
+}
+
+// BenchmarkMaps 负载 × key 空间 × GOMAXPROCS × 实现 的基准矩阵
+func BenchmarkMaps(b *testing.B) {
+	for _, w := range benchWorkloads() {
+		for _, keys := range benchKeySpaces {
+			for _, procs := range benchProcs {
+				for _, impl := range benchImpls() {
+					name := fmt.Sprintf("%s/keys=%d/procs=%d/%s", w.name, keys, procs, impl.name)
+					b.Run(name, func(b *testing.B) {
+						defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+						runMapBenchmark(b, impl, w, keys)
+					})
+				}
+			}
+		}
+	}
+}
+
+// TestMapBenchmarkReport 跑完整个基准矩阵，按场景打印每种实现的耗时以及相对 MutexMap 的倍数
+func TestMapBenchmarkReport(t *testing.T) {
+	if !*mapBenchReport {
+		t.Skip("pass -mapbench.report to run the benchmark matrix")
+	}
+
+	type row struct {
+		scenario string
+		nsPerOp  map[string]float64
+	}
+	var rows []row
+	impls := benchImpls()
+	for _, w := range benchWorkloads() {
+		for _, keys := range benchKeySpaces {
+			for _, procs := range benchProcs {
+				r := row{
+					scenario: fmt.Sprintf("%s keys=%d procs=%d", w.name, keys, procs),
+					nsPerOp:  make(map[string]float64),
+				}
+				for _, impl := range impls {
+					prev := runtime.GOMAXPROCS(procs)
+					res := testing.Benchmark(func(b *testing.B) { runMapBenchmark(b, impl, w, keys) })
+					runtime.GOMAXPROCS(prev)
+					r.nsPerOp[impl.name] = float64(res.T.Nanoseconds()) / float64(res.N)
+				}
+				rows = append(rows, r)
+			}
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "scenario\t")
+	for _, impl := range impls {
+		fmt.Fprintf(tw, "%s ns/op\tvs MutexMap\t", impl.name)
+	}
+	fmt.Fprintln(tw, "fastest\t")
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t", r.scenario)
+		base := r.nsPerOp["MutexMap"]
+		for _, impl := range impls {
+			ns := r.nsPerOp[impl.name]
+			fmt.Fprintf(tw, "%.1f\t%.2fx\t", ns, base/ns)
+		}
+		names := make([]string, 0, len(impls))
+		for _, impl := range impls {
+			names = append(names, impl.name)
+		}
+		sort.Slice(names, func(i, j int) bool { return r.nsPerOp[names[i]] < r.nsPerOp[names[j]] })
+		fmt.Fprintf(tw, "%s\t\n", names[0])
+	}
+	tw.Flush()
+}
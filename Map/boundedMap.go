@@ -0,0 +1,207 @@
+package Map
+
+// BoundedMap 有容量上限的分片 map。容量可以是条目数量，也可以是调用方估算的字节数；
+// 超过容量时按构造时选定的策略淘汰条目，并通过回调报告每一次淘汰。
+//
+// 与 SharedMap 一样按 key 的哈希值分片加锁，容量平均分给各个分片，每个分片独立淘汰，
+// 因此整体不会超过容量，但 key 分布不均时可能在总量达到容量之前就开始淘汰。
+// LRU/internal/cache 是专门为磁盘预读设计的缓存，BoundedMap 是通用的有界存储
+type BoundedMap[K comparable, V any] struct {
+	table    *shardTable[K, boundedEntry[V]] // 分片和 SharedMap 相同，分片数量固定，不支持 Resize
+	limits   []*boundedLimit[K]              // 与 table 中的分片一一对应，由对应分片的锁保护
+	hash     HashFunc[K]
+	capacity int64
+	sizeOf   func(key K, value V) int64 // 条目大小的估算函数
+	onEvict  func(key K, value V)       // 淘汰回调，在分片锁外调用
+}
+
+// boundedLimit 一个分片的淘汰状态。LRU 和 LFU 在读取时也要修改访问记录，因此除 Peek 外都加写锁
+type boundedLimit[K comparable] struct {
+	policy   evictionPolicy[K]
+	size     int64
+	capacity int64
+}
+
+type boundedEntry[V any] struct {
+	value V
+	size  int64
+}
+
+// NewBoundedMap 创建容量为 capacity、按 policy 淘汰的 BoundedMap。
+// shardCount 小于等于 0 时使用 DefaultShardCount，并且不会超过 capacity；
+// sizeOf 为 nil 时每个条目大小为 1，capacity 即条目数量上限；onEvict 可以为 nil
+func NewBoundedMap[K comparable, V any](shardCount int, capacity int64, policy EvictionPolicy,
+	sizeOf func(key K, value V) int64, onEvict func(key K, value V)) *BoundedMap[K, V] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if shardCount <= 0 {
+		shardCount = DefaultShardCount
+	}
+	if int64(shardCount) > capacity {
+		shardCount = int(capacity)
+	}
+	if sizeOf == nil {
+		sizeOf = func(K, V) int64 { return 1 }
+	}
+	m := &BoundedMap[K, V]{
+		table:    newShardTable[K, boundedEntry[V]](shardCount),
+		limits:   make([]*boundedLimit[K], shardCount),
+		hash:     FNVHash[K],
+		capacity: capacity,
+		sizeOf:   sizeOf,
+		onEvict:  onEvict,
+	}
+	// 容量除不尽时余数分给前面的分片，各分片容量之和等于 capacity
+	per, rest := capacity/int64(shardCount), capacity%int64(shardCount)
+	for i := range m.limits {
+		c := per
+		if int64(i) < rest {
+			c++
+		}
+		m.limits[i] = &boundedLimit[K]{policy: newEvictionPolicy[K](policy), capacity: c}
+	}
+	return m
+}
+
+// shard 返回 key 所在的分片和它的淘汰状态
+func (m *BoundedMap[K, V]) shard(key K) (*mapShard[K, boundedEntry[V]], *boundedLimit[K]) {
+	i := m.table.index(m.hash(key))
+	return m.table.shards[i], m.limits[i]
+}
+
+// Put 写入键值对，超过容量时先淘汰同一分片中的其他条目。
+// 单个条目的大小超过分片容量时无法保存，只把这个条目报告给回调，分片中的其他条目不受影响；
+// key 原来的值已被覆盖，同样会被删除
+func (m *BoundedMap[K, V]) Put(key K, value V) {
+	s, l := m.shard(key)
+	size := m.sizeOf(key, value)
+
+	s.Lock()
+	if size > l.capacity {
+		if _, ok := s.items[key]; ok {
+			m.removeLocked(s, l, key)
+		}
+		s.Unlock()
+		m.report([]mapItem[K, V]{{key: key, value: value}})
+		return
+	}
+	if old, ok := s.items[key]; ok {
+		l.size -= old.size
+		l.policy.access(key)
+	} else {
+		l.policy.add(key)
+	}
+	s.items[key] = boundedEntry[V]{value: value, size: size}
+	l.size += size
+	evicted := m.evict(s, l, key)
+	s.Unlock()
+
+	m.report(evicted)
+}
+
+// Get 返回 key 对应的值以及 key 是否存在，并记录一次访问
+func (m *BoundedMap[K, V]) Get(key K) (V, bool) {
+	s, l := m.shard(key)
+	s.Lock()
+	defer s.Unlock()
+	e, ok := s.items[key]
+	if ok {
+		l.policy.access(key)
+	}
+	return e.value, ok
+}
+
+// Peek 返回 key 对应的值，不记录访问，不影响淘汰顺序
+func (m *BoundedMap[K, V]) Peek(key K) (V, bool) {
+	s, _ := m.shard(key)
+	s.RLock()
+	defer s.RUnlock()
+	e, ok := s.items[key]
+	return e.value, ok
+}
+
+// Remove 删除 key，返回删除前 key 是否存在。主动删除不会触发淘汰回调
+func (m *BoundedMap[K, V]) Remove(key K) bool {
+	s, l := m.shard(key)
+	s.Lock()
+	defer s.Unlock()
+	_, ok := s.items[key]
+	if ok {
+		m.removeLocked(s, l, key)
+	}
+	return ok
+}
+
+// Len 返回条目数量
+func (m *BoundedMap[K, V]) Len() int {
+	n := 0
+	for _, s := range m.table.shards {
+		s.RLock()
+		n += len(s.items)
+		s.RUnlock()
+	}
+	return n
+}
+
+// Size 返回所有条目的估算大小之和
+func (m *BoundedMap[K, V]) Size() int64 {
+	var n int64
+	for i, s := range m.table.shards {
+		s.RLock()
+		n += m.limits[i].size
+		s.RUnlock()
+	}
+	return n
+}
+
+// Capacity 返回容量上限
+func (m *BoundedMap[K, V]) Capacity() int64 {
+	return m.capacity
+}
+
+// Range 先复制所有键值对再调用 f，f 返回 false 时停止遍历，遍历不记录访问
+func (m *BoundedMap[K, V]) Range(f func(key K, value V) bool) {
+	var items []mapItem[K, V]
+	for _, s := range m.table.shards {
+		s.RLock()
+		for k, e := range s.items {
+			items = append(items, mapItem[K, V]{key: k, value: e.value})
+		}
+		s.RUnlock()
+	}
+	for _, item := range items {
+		if !f(item.key, item.value) {
+			return
+		}
+	}
+}
+
+func (m *BoundedMap[K, V]) report(evicted []mapItem[K, V]) {
+	if m.onEvict == nil {
+		return
+	}
+	for _, item := range evicted {
+		m.onEvict(item.key, item.value)
+	}
+}
+
+// evict 淘汰分片中 skip 以外的条目直到大小不超过容量，返回被淘汰的条目，调用方需要持有分片的写锁
+func (m *BoundedMap[K, V]) evict(s *mapShard[K, boundedEntry[V]], l *boundedLimit[K], skip K) []mapItem[K, V] {
+	var evicted []mapItem[K, V]
+	for l.size > l.capacity {
+		k, ok := l.policy.victim(skip)
+		if !ok {
+			break
+		}
+		evicted = append(evicted, mapItem[K, V]{key: k, value: s.items[k].value})
+		m.removeLocked(s, l, k)
+	}
+	return evicted
+}
+
+func (m *BoundedMap[K, V]) removeLocked(s *mapShard[K, boundedEntry[V]], l *boundedLimit[K], key K) {
+	l.size -= s.items[key].size
+	delete(s.items, key)
+	l.policy.remove(key)
+}
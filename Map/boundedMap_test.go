@@ -0,0 +1,156 @@
+package Map
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestBounded 创建单分片的 BoundedMap，淘汰顺序是确定的，被淘汰的 key 记录在返回的切片中
+func newTestBounded(capacity int64, policy EvictionPolicy) (*BoundedMap[string, int], *[]string) {
+	var evicted []string
+	m := NewBoundedMap[string, int](1, capacity, policy, nil, func(key string, _ int) {
+		evicted = append(evicted, key)
+	})
+	return m, &evicted
+}
+
+func TestBoundedMapPolicies(t *testing.T) {
+	cases := []struct {
+		policy EvictionPolicy
+		want   string // 写入 a b c，访问 a a b 后写入 d 时被淘汰的 key
+	}{
+		{PolicyLRU, "c"},
+		{PolicyFIFO, "a"},
+		{PolicyLFU, "c"},
+	}
+	for _, c := range cases {
+		t.Run(c.policy.String(), func(t *testing.T) {
+			m, evicted := newTestBounded(3, c.policy)
+			m.Put("a", 1)
+			m.Put("b", 2)
+			m.Put("c", 3)
+			m.Get("a")
+			m.Get("a")
+			m.Get("b")
+			m.Put("d", 4)
+			if len(*evicted) != 1 || (*evicted)[0] != c.want {
+				t.Fatalf("evicted %v, want [%s]", *evicted, c.want)
+			}
+			if m.Len() != 3 {
+				t.Fatalf("Len() = %d, want 3", m.Len())
+			}
+		})
+	}
+}
+
+func TestBoundedMapLFUTies(t *testing.T) {
+	m, evicted := newTestBounded(2, PolicyLFU)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Get("a")
+	m.Get("b")
+	m.Get("a")
+	m.Remove("a") // 删除后 minFreq 失效
+	m.Put("c", 3)
+	m.Put("d", 4) // b 访问过一次，c 没有访问过
+	if len(*evicted) != 1 || (*evicted)[0] != "c" {
+		t.Fatalf("evicted %v, want [c]", *evicted)
+	}
+	if _, ok := m.Peek("d"); !ok {
+		t.Fatalf("the key being written must not be evicted")
+	}
+}
+
+// TestLFUPolicySkip 最少的桶中只有 skip 时从下一个桶淘汰，之后仍然先淘汰 skip 所在的桶
+func TestLFUPolicySkip(t *testing.T) {
+	p := newEvictionPolicy[string](PolicyLFU)
+	p.add("a")
+	p.access("a")
+	p.add("b")
+	if k, ok := p.victim("b"); !ok || k != "a" {
+		t.Fatalf("victim(b) = %q, %v, want a", k, ok)
+	}
+	if k, ok := p.victim(""); !ok || k != "b" {
+		t.Fatalf("victim() = %q, %v, want b", k, ok)
+	}
+	p.remove("b")
+	if k, ok := p.victim("a"); ok {
+		t.Fatalf("victim(a) = %q with only a left", k)
+	}
+}
+
+func TestBoundedMapRandom(t *testing.T) {
+	m, evicted := newTestBounded(3, PolicyRandom)
+	for i := 0; i < 10; i++ {
+		m.Put(fmt.Sprint(i), i)
+		if _, ok := m.Peek(fmt.Sprint(i)); !ok {
+			t.Fatalf("the key being written was evicted")
+		}
+	}
+	if m.Len() != 3 || len(*evicted) != 7 {
+		t.Fatalf("Len() = %d with %d evictions, want 3 and 7", m.Len(), len(*evicted))
+	}
+}
+
+func TestBoundedMapSizeEstimator(t *testing.T) {
+	var evicted []string
+	m := NewBoundedMap[string, string](1, 10, PolicyLRU,
+		func(_ string, v string) int64 { return int64(len(v)) },
+		func(key, _ string) { evicted = append(evicted, key) })
+
+	m.Put("a", "xxxx")
+	m.Put("b", "xxxx")
+	m.Put("a", "xx") // 更新时大小按新值计算
+	if m.Size() != 6 || len(evicted) != 0 {
+		t.Fatalf("Size() = %d with evictions %v, want 6 and none", m.Size(), evicted)
+	}
+	m.Put("c", "xxxxxx") // 需要淘汰 b
+	if m.Size() != 8 || len(evicted) != 1 || evicted[0] != "b" {
+		t.Fatalf("Size() = %d, evicted %v", m.Size(), evicted)
+	}
+	m.Put("huge", "xxxxxxxxxxxx") // 比容量还大，只拒绝它自己，已有的条目保留
+	if _, ok := m.Get("huge"); ok || m.Size() != 8 || len(evicted) != 2 || evicted[1] != "huge" {
+		t.Fatalf("oversized entry: Size() = %d, evicted %v", m.Size(), evicted)
+	}
+	m.Put("a", "xxxxxxxxxxxx") // 已有 key 写入过大的值，旧值被覆盖后一起删除
+	if _, ok := m.Get("a"); ok || m.Size() != 6 || len(evicted) != 3 || evicted[2] != "a" {
+		t.Fatalf("oversized update: Size() = %d, evicted %v", m.Size(), evicted)
+	}
+	if v, ok := m.Get("c"); !ok || v != "xxxxxx" || m.Len() != 1 {
+		t.Fatalf("Get(c) = %q, %v with Len() = %d", v, ok, m.Len())
+	}
+}
+
+// TestBoundedMapConcurrent 并发读写后，淘汰数量加上剩余数量等于写入的不同 key 数量，且不超过容量
+func TestBoundedMapConcurrent(t *testing.T) {
+	for _, policy := range []EvictionPolicy{PolicyLRU, PolicyFIFO, PolicyRandom, PolicyLFU} {
+		t.Run(policy.String(), func(t *testing.T) {
+			const capacity, workers, perWorker = 100, 8, 1000
+			var evictions atomic.Int64
+			m := NewBoundedMap[int, int](8, capacity, policy, nil, func(int, int) { evictions.Add(1) })
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					r := rand.New(rand.NewSource(int64(w)))
+					for i := 0; i < perWorker; i++ {
+						m.Put(w*perWorker+i, i)
+						m.Get(w*perWorker + r.Intn(i+1))
+					}
+				}(w)
+			}
+			wg.Wait()
+
+			if m.Size() > capacity || int64(m.Len()) != m.Size() {
+				t.Fatalf("Size() = %d, Len() = %d, capacity %d", m.Size(), m.Len(), capacity)
+			}
+			if got := evictions.Load() + int64(m.Len()); got != workers*perWorker {
+				t.Fatalf("evictions + Len() = %d, want %d", got, workers*perWorker)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package Map
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// COWMap 写时复制的 map，适合路由表、特性开关这类读远多于写的场景。
+//
+// 读操作通过 atomic.Pointer 读取一个不可变的版本，完全不加锁；
+// 写操作在互斥锁内复制当前版本、修改副本后原子地替换，代价与元素数量成正比。
+// 开启写合并后，写入先缓存起来，最多等待一个合并间隔后一次性发布，多次写入只复制一次
+type COWMap[K comparable, V any] struct {
+	current atomic.Pointer[COWSnapshot[K, V]]
+
+	mu       sync.Mutex
+	coalesce time.Duration  // 写合并间隔，0 表示每次写入立即发布
+	pending  map[K]cowOp[V] // 等待发布的写入
+	timer    *time.Timer    // 到期后发布 pending
+}
+
+// cowOp 一次写入或删除
+type cowOp[V any] struct {
+	value   V
+	deleted bool
+}
+
+// COWSnapshot COWMap 的一个不可变版本，可以在不加锁的情况下一致地读取和遍历
+type COWSnapshot[K comparable, V any] struct {
+	items   map[K]V
+	version uint64
+}
+
+// NewCOWMap 创建 COWMap。coalesce 大于 0 时开启写合并：
+// 写入最多延迟 coalesce 才对读者可见，期间的所有写入合并为一个新版本
+func NewCOWMap[K comparable, V any](coalesce time.Duration) *COWMap[K, V] {
+	m := &COWMap[K, V]{coalesce: coalesce}
+	m.current.Store(&COWSnapshot[K, V]{items: make(map[K]V)})
+	return m
+}
+
+// Get 返回 key 在当前版本中的值，不加锁
+func (m *COWMap[K, V]) Get(key K) (V, bool) {
+	v, ok := m.current.Load().items[key]
+	return v, ok
+}
+
+// Len 返回当前版本的元素数量
+func (m *COWMap[K, V]) Len() int {
+	return len(m.current.Load().items)
+}
+
+// Range 遍历当前版本，遍历期间的写入不影响本次遍历
+func (m *COWMap[K, V]) Range(f func(key K, value V) bool) {
+	m.current.Load().Range(f)
+}
+
+// Snapshot 返回当前版本
+func (m *COWMap[K, V]) Snapshot() *COWSnapshot[K, V] {
+	return m.current.Load()
+}
+
+// Put 写入键值对
+func (m *COWMap[K, V]) Put(key K, value V) {
+	m.write(key, cowOp[V]{value: value})
+}
+
+// Delete 删除 key
+func (m *COWMap[K, V]) Delete(key K) {
+	m.write(key, cowOp[V]{deleted: true})
+}
+
+// Batch 在一个新版本中完成 fn 中的所有修改，读者要么看到全部修改，要么一个也看不到。
+// Batch 总是立即发布，开启写合并时会连同尚未发布的写入一起发布
+func (m *COWMap[K, V]) Batch(fn func(b *COWBatch[K, V])) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.publishLocked(func(items map[K]V) {
+		applyCOWOps(items, m.pending)
+		fn(&COWBatch[K, V]{items: items})
+	})
+	m.resetPendingLocked()
+}
+
+// Flush 立即发布写合并中尚未发布的写入
+func (m *COWMap[K, V]) Flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pending) > 0 {
+		m.publishLocked(func(items map[K]V) { applyCOWOps(items, m.pending) })
+	}
+	m.resetPendingLocked()
+}
+
+func (m *COWMap[K, V]) write(key K, op cowOp[V]) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.coalesce <= 0 {
+		m.publishLocked(func(items map[K]V) { applyCOWOps(items, map[K]cowOp[V]{key: op}) })
+		return
+	}
+	if m.pending == nil {
+		m.pending = make(map[K]cowOp[V])
+		m.timer = time.AfterFunc(m.coalesce, m.Flush)
+	}
+	m.pending[key] = op
+}
+
+// publishLocked 复制当前版本，用 modify 修改副本后发布为新版本，调用方需要持有 mu
+func (m *COWMap[K, V]) publishLocked(modify func(items map[K]V)) {
+	cur := m.current.Load()
+	items := make(map[K]V, len(cur.items))
+	for k, v := range cur.items {
+		items[k] = v
+	}
+	modify(items)
+	m.current.Store(&COWSnapshot[K, V]{items: items, version: cur.version + 1})
+}
+
+func (m *COWMap[K, V]) resetPendingLocked() {
+	m.pending = nil
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
+
+func applyCOWOps[K comparable, V any](items map[K]V, ops map[K]cowOp[V]) {
+	for k, op := range ops {
+		if op.deleted {
+			delete(items, k)
+		} else {
+			items[k] = op.value
+		}
+	}
+}
+
+// COWBatch Batch 中使用的可写副本，只能在 Batch 的回调中使用
+type COWBatch[K comparable, V any] struct {
+	items map[K]V
+}
+
+// Get 返回副本中 key 的值，能读到本次 Batch 之前的修改
+func (b *COWBatch[K, V]) Get(key K) (V, bool) {
+	v, ok := b.items[key]
+	return v, ok
+}
+
+// Put 在副本中写入键值对
+func (b *COWBatch[K, V]) Put(key K, value V) {
+	b.items[key] = value
+}
+
+// Delete 在副本中删除 key
+func (b *COWBatch[K, V]) Delete(key K) {
+	delete(b.items, key)
+}
+
+// Get 返回 key 在该版本中的值
+func (s *COWSnapshot[K, V]) Get(key K) (V, bool) {
+	v, ok := s.items[key]
+	return v, ok
+}
+
+// Len 返回该版本的元素数量
+func (s *COWSnapshot[K, V]) Len() int {
+	return len(s.items)
+}
+
+// Version 返回版本号，每发布一个新版本加 1
+func (s *COWSnapshot[K, V]) Version() uint64 {
+	return s.version
+}
+
+// Range 遍历该版本的所有键值对，f 返回 false 时停止遍历
+func (s *COWSnapshot[K, V]) Range(f func(key K, value V) bool) {
+	for k, v := range s.items {
+		if !f(k, v) {
+			return
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package Map
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCOWMap(t *testing.T) {
+	m := NewCOWMap[string, int](0)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	snap := m.Snapshot()
+
+	m.Put("a", 10)
+	m.Delete("b")
+	if v, _ := m.Get("a"); v != 10 || m.Len() != 1 {
+		t.Fatalf("Get(a) = %d, Len() = %d", v, m.Len())
+	}
+	// 旧版本不受之后写入的影响
+	if v, _ := snap.Get("a"); v != 1 || snap.Len() != 2 || snap.Version() != 2 {
+		t.Fatalf("snapshot changed: a = %d, Len() = %d, Version() = %d", v, snap.Len(), snap.Version())
+	}
+
+	m.Batch(func(b *COWBatch[string, int]) {
+		v, _ := b.Get("a")
+		b.Put("a", v+1)
+		b.Put("c", 3)
+		b.Delete("missing")
+	})
+	if s := m.Snapshot(); s.Version() != 5 || s.Len() != 2 {
+		t.Fatalf("batch should publish exactly one version, got version %d with %d items", s.Version(), s.Len())
+	}
+	if v, _ := m.Get("a"); v != 11 {
+		t.Fatalf("Get(a) = %d after batch, want 11", v)
+	}
+}
+
+func TestCOWMapCoalescing(t *testing.T) {
+	m := NewCOWMap[int, int](time.Hour)
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	m.Delete(0)
+	if m.Len() != 0 {
+		t.Fatalf("coalesced writes visible before flush")
+	}
+	m.Flush()
+	if s := m.Snapshot(); s.Len() != 99 || s.Version() != 1 {
+		t.Fatalf("flush published %d items as version %d, want 99 items in one version", s.Len(), s.Version())
+	}
+
+	// Batch 连同合并中的写入一起发布
+	m.Put(200, 1)
+	m.Batch(func(b *COWBatch[int, int]) {
+		if _, ok := b.Get(200); !ok {
+			t.Fatalf("batch does not see pending coalesced write")
+		}
+	})
+	if _, ok := m.Get(200); !ok || m.Snapshot().Version() != 2 {
+		t.Fatalf("pending write not published with the batch")
+	}
+
+	// 合并间隔到期后自动发布
+	m = NewCOWMap[int, int](time.Millisecond)
+	m.Put(1, 1)
+	deadline := time.Now().Add(time.Second)
+	for m.Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("coalesced write was never published")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestCOWMapConsistentReads 并发 Batch 始终保持 x + y == 0，无锁读者从任一版本中读到的都满足该不变式
+func TestCOWMapConsistentReads(t *testing.T) {
+	m := NewCOWMap[string, int](0)
+	m.Batch(func(b *COWBatch[string, int]) {
+		b.Put("x", 0)
+		b.Put("y", 0)
+	})
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				s := m.Snapshot()
+				x, _ := s.Get("x")
+				y, _ := s.Get("y")
+				if x+y != 0 {
+					t.Errorf("inconsistent snapshot %d: x=%d y=%d", s.Version(), x, y)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 2000; i++ {
+		m.Batch(func(b *COWBatch[string, int]) {
+			x, _ := b.Get("x")
+			y, _ := b.Get("y")
+			b.Put("x", x+1)
+			b.Put("y", y-1)
+		})
+	}
+	stop.Store(true)
+	wg.Wait()
+	if x, _ := m.Get("x"); x != 2000 {
+		t.Fatalf("x = %d, want 2000", x)
+	}
+}
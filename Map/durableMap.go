@@ -0,0 +1,543 @@
+package Map
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrClosed DurableMap 已经关闭
+	ErrClosed = errors.New("durable map is closed")
+	// ErrCorruptSnapshot 快照文件损坏。快照通过原子重命名生成，正常情况下不会损坏，因此不做修复
+	ErrCorruptSnapshot = errors.New("snapshot is corrupt")
+	// ErrCorruptLog 日志中间的记录损坏，之后还有数据。宕机只会损坏尾部，这种情况不自动截断，避免丢掉后面的记录
+	ErrCorruptLog = errors.New("wal is corrupt")
+)
+
+// SyncPolicy 写日志后何时调用 fsync
+type SyncPolicy int
+
+const (
+	SyncAlways   SyncPolicy = iota // 每次写入后 fsync，返回即落盘
+	SyncInterval                   // 后台按 SyncInterval 定时 fsync，宕机最多丢失一个间隔内的写入
+	SyncNever                      // 从不主动 fsync，由操作系统决定何时落盘
+)
+
+const (
+	walFile         = "wal"
+	snapshotFile    = "snapshot"
+	frameHeaderSize = 8       // 4 字节长度 + 4 字节 CRC32
+	maxFrameSize    = 1 << 30 // 超过该长度的记录视为损坏
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errBadFrame 记录的长度或校验和不正确
+var errBadFrame = errors.New("bad frame")
+
+// DurableOptions DurableMap 的配置，零值可用：gob 编码、每次写入都 fsync、不自动生成快照
+type DurableOptions struct {
+	Codec            Codec         // 为 nil 时使用 GobCodec
+	Sync             SyncPolicy    // fsync 策略
+	SyncInterval     time.Duration // SyncInterval 策略下的刷盘间隔，默认 1 秒
+	SnapshotEvery    int           // 日志记录数量达到该值时自动生成快照，0 表示不按数量生成
+	SnapshotInterval time.Duration // 定时生成快照的间隔，0 表示不定时生成
+}
+
+// RecoveryInfo 打开 DurableMap 时的恢复情况
+type RecoveryInfo struct {
+	SnapshotEntries int   // 从快照中加载的键值对数量
+	Replayed        int   // 从日志中重放的记录数量
+	TruncatedBytes  int64 // 日志尾部不完整或损坏而被截掉的字节数
+}
+
+// DurableMap 可以在重启后恢复数据的 map。
+//
+// 每次 Put 和 Remove 先以 [长度][CRC32][记录] 的格式追加到预写日志（WAL），再修改内存中的数据。
+// 快照把当前全部数据写入临时文件，fsync 后原子地重命名为快照文件，然后清空日志。
+// 启动时先加载快照，再重放日志；日志尾部因宕机而写了一半或校验失败的记录会被截掉。
+// 快照和日志清空之间宕机时，日志中的记录会在快照上重放一遍，由于每条记录都是完整的写入或删除，结果不变
+type DurableMap[K comparable, V any] struct {
+	mu       sync.RWMutex
+	items    map[K]V
+	dir      string
+	opts     DurableOptions
+	wal      walWriter
+	walSize  int64 // 日志中完整记录的总长度，写入失败时截回到这里
+	records  int   // 上次快照之后日志中的记录数量
+	dirty    bool  // 有尚未 fsync 的写入
+	closed   bool
+	recovery RecoveryInfo
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// walWriter 日志文件用到的操作，测试时可以替换以模拟 fsync 失败
+type walWriter interface {
+	io.Writer
+	Seek(offset int64, whence int) (int64, error)
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// walOp 日志记录的操作类型
+type walOp uint8
+
+const (
+	walPut walOp = iota + 1
+	walRemove
+)
+
+// walRecord 日志和快照中的一条记录，字段需要导出以便编解码
+type walRecord[K comparable, V any] struct {
+	Op    walOp
+	Key   K
+	Value V
+}
+
+// OpenDurableMap 打开 dir 目录下的 DurableMap，目录不存在时创建，存在时从快照和日志中恢复数据
+func OpenDurableMap[K comparable, V any](dir string, opts DurableOptions) (*DurableMap[K, V], error) {
+	if opts.Codec == nil {
+		opts.Codec = GobCodec{}
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = time.Second
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	m := &DurableMap[K, V]{items: make(map[K]V), dir: dir, opts: opts}
+	if err := m.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := m.replayLog(); err != nil {
+		return nil, err
+	}
+
+	if opts.Sync == SyncInterval || opts.SnapshotInterval > 0 {
+		m.stop = make(chan struct{})
+		m.done = make(chan struct{})
+		go m.background()
+	}
+	return m, nil
+}
+
+// Put 写入键值对，日志写入失败时内存中的数据不变并返回错误
+func (m *DurableMap[K, V]) Put(key K, value V) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.append(walRecord[K, V]{Op: walPut, Key: key, Value: value}); err != nil {
+		return err
+	}
+	m.items[key] = value
+	m.maybeSnapshot()
+	return nil
+}
+
+// Remove 删除 key，key 不存在时不写日志
+func (m *DurableMap[K, V]) Remove(key K) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	if _, ok := m.items[key]; !ok {
+		return nil
+	}
+	if err := m.append(walRecord[K, V]{Op: walRemove, Key: key}); err != nil {
+		return err
+	}
+	delete(m.items, key)
+	m.maybeSnapshot()
+	return nil
+}
+
+// Get 返回 key 对应的值以及 key 是否存在
+func (m *DurableMap[K, V]) Get(key K) (V, bool) {
+	m.mu.RLock()
+	v, ok := m.items[key]
+	m.mu.RUnlock()
+	return v, ok
+}
+
+// Len 返回元素个数
+func (m *DurableMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.items)
+}
+
+// Range 在读锁下遍历所有键值对，f 返回 false 时停止遍历，f 中不能修改 DurableMap
+func (m *DurableMap[K, V]) Range(f func(key K, value V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for k, v := range m.items {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+// Recovery 返回打开时的恢复情况
+func (m *DurableMap[K, V]) Recovery() RecoveryInfo {
+	return m.recovery
+}
+
+// Sync 把日志中尚未落盘的写入 fsync 到磁盘
+func (m *DurableMap[K, V]) Sync() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	return m.syncLocked()
+}
+
+// Snapshot 立即生成快照并清空日志。生成期间持有写锁，读写都会等待
+func (m *DurableMap[K, V]) Snapshot() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	return m.snapshotLocked()
+}
+
+// Close 停止后台任务，把日志落盘后关闭，可以重复调用
+func (m *DurableMap[K, V]) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.mu.Unlock()
+
+	if m.stop != nil {
+		close(m.stop)
+		<-m.done
+	}
+	err := m.wal.Sync()
+	if cerr := m.wal.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// background 定时 fsync 和生成快照
+func (m *DurableMap[K, V]) background() {
+	defer close(m.done)
+	var syncC, snapshotC <-chan time.Time
+	if m.opts.Sync == SyncInterval {
+		t := time.NewTicker(m.opts.SyncInterval)
+		defer t.Stop()
+		syncC = t.C
+	}
+	if m.opts.SnapshotInterval > 0 {
+		t := time.NewTicker(m.opts.SnapshotInterval)
+		defer t.Stop()
+		snapshotC = t.C
+	}
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-syncC:
+			m.Sync()
+		case <-snapshotC:
+			m.mu.Lock()
+			if !m.closed && m.records > 0 {
+				m.snapshotLocked()
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// append 把一条记录追加到日志，调用方需要持有写锁
+func (m *DurableMap[K, V]) append(rec walRecord[K, V]) error {
+	if m.closed {
+		return ErrClosed
+	}
+	payload, err := m.opts.Codec.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	frame := encodeFrame(payload)
+	if _, err := m.wal.Write(frame); err != nil {
+		// 截掉写了一半的记录，否则后续的记录在恢复时会被当作损坏的尾部丢弃
+		m.rollback()
+		return err
+	}
+	if m.opts.Sync == SyncAlways {
+		if err := m.wal.Sync(); err != nil {
+			// 调用方会认为写入失败，记录不能留在日志中，否则重启后会被重放
+			m.rollback()
+			return err
+		}
+	} else {
+		m.dirty = true
+	}
+	m.walSize += int64(len(frame))
+	m.records++
+	return nil
+}
+
+// rollback 把日志截回到最后一条完整记录的末尾
+func (m *DurableMap[K, V]) rollback() {
+	m.wal.Truncate(m.walSize)
+	m.wal.Seek(m.walSize, io.SeekStart)
+}
+
+func (m *DurableMap[K, V]) syncLocked() error {
+	if !m.dirty {
+		return nil
+	}
+	if err := m.wal.Sync(); err != nil {
+		return err
+	}
+	m.dirty = false
+	return nil
+}
+
+// maybeSnapshot 日志记录数量达到阈值时生成快照。
+// 写入本身已经记录在日志中，快照失败不影响本次写入，下一次写入时会重试
+func (m *DurableMap[K, V]) maybeSnapshot() {
+	if m.opts.SnapshotEvery > 0 && m.records >= m.opts.SnapshotEvery {
+		m.snapshotLocked()
+	}
+}
+
+// snapshotLocked 生成快照并清空日志，调用方需要持有写锁
+func (m *DurableMap[K, V]) snapshotLocked() error {
+	tmp := filepath.Join(m.dir, snapshotFile+".tmp")
+	if err := m.writeSnapshot(tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(m.dir, snapshotFile)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := syncDir(m.dir); err != nil {
+		return err
+	}
+
+	// 快照已经包含日志中的全部修改，可以清空日志
+	if err := m.wal.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := m.wal.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	m.walSize, m.records = 0, 0
+	m.dirty = true
+	return m.syncLocked()
+}
+
+func (m *DurableMap[K, V]) writeSnapshot(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for k, v := range m.items {
+		payload, err := m.opts.Codec.Marshal(walRecord[K, V]{Op: walPut, Key: k, Value: v})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(encodeFrame(payload)); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// loadSnapshot 加载快照，快照不存在时什么也不做
+func (m *DurableMap[K, V]) loadSnapshot() error {
+	f, err := os.Open(filepath.Join(m.dir, snapshotFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		payload, err := readFrame(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCorruptSnapshot, err)
+		}
+		var rec walRecord[K, V]
+		if err := m.opts.Codec.Unmarshal(payload, &rec); err != nil {
+			return fmt.Errorf("%w: %v", ErrCorruptSnapshot, err)
+		}
+		m.items[rec.Key] = rec.Value
+		m.recovery.SnapshotEntries++
+	}
+}
+
+// replayLog 在快照的基础上重放日志，并截掉尾部不完整或校验失败的记录。
+// 校验通过但无法解码的记录说明编解码器配置错误，中间的记录损坏说明不是宕机造成的，
+// 这两种情况返回错误而不是截断，避免误删数据
+func (m *DurableMap[K, V]) replayLog() error {
+	f, err := os.OpenFile(filepath.Join(m.dir, walFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		payload, err := readFrame(r)
+		if err == io.EOF {
+			break
+		}
+		// 头部的长度被改大时记录看起来一直延伸到文件末尾，和没写完的最后一条记录一样需要检查
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errBadFrame) {
+			tail, terr := isTornTail(f, offset, info.Size())
+			if terr != nil {
+				f.Close()
+				return terr
+			}
+			if !tail {
+				f.Close()
+				return fmt.Errorf("%w: bad record at offset %d of %d bytes", ErrCorruptLog, offset, info.Size())
+			}
+			break
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+		var rec walRecord[K, V]
+		if err := m.opts.Codec.Unmarshal(payload, &rec); err != nil {
+			f.Close()
+			return fmt.Errorf("decode wal record at offset %d: %w", offset, err)
+		}
+		switch rec.Op {
+		case walPut:
+			m.items[rec.Key] = rec.Value
+		case walRemove:
+			delete(m.items, rec.Key)
+		}
+		offset += int64(frameHeaderSize + len(payload))
+		m.records++
+		m.recovery.Replayed++
+	}
+
+	if info.Size() > offset {
+		m.recovery.TruncatedBytes = info.Size() - offset
+		if err := f.Truncate(offset); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	m.wal, m.walSize = f, offset
+	return nil
+}
+
+// isTornTail 判断 offset 处读不出完整记录的原因是否是宕机留下的尾部。宕机只会破坏最后写入的字节，
+// 所以 offset 之后还能找到一条校验通过的记录时，说明是中间的记录损坏（例如长度被改大，
+// 看起来延伸到了文件末尾）。之后的每个位置都尝试解析一次，只在恢复遇到坏记录时执行
+func isTornTail(f *os.File, offset, size int64) (bool, error) {
+	rest := make([]byte, size-offset)
+	if _, err := f.ReadAt(rest, offset); err != nil {
+		return false, err
+	}
+	for i := 1; i+frameHeaderSize <= len(rest); i++ {
+		if validFrame(rest[i:]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// validFrame 返回 b 是否以一条长度和校验和都正确的记录开头
+func validFrame(b []byte) bool {
+	n := int64(binary.LittleEndian.Uint32(b[0:4]))
+	if n == 0 || n > int64(len(b)-frameHeaderSize) {
+		return false
+	}
+	return crc32.Checksum(b[frameHeaderSize:frameHeaderSize+n], crcTable) == binary.LittleEndian.Uint32(b[4:8])
+}
+
+// encodeFrame 给记录加上长度和校验和
+func encodeFrame(payload []byte) []byte {
+	frame := make([]byte, frameHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.Checksum(payload, crcTable))
+	copy(frame[frameHeaderSize:], payload)
+	return frame
+}
+
+// readFrame 读取一条记录。正好读到文件末尾时返回 io.EOF，
+// 记录不完整时返回 io.ErrUnexpectedEOF，长度或校验和不正确时返回 errBadFrame
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.LittleEndian.Uint32(header[0:4])
+	// 写入的记录不会为空，长度为 0 通常是文件系统在宕机后留下的零填充
+	if n == 0 || n > maxFrameSize {
+		return nil, errBadFrame
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[4:8]) {
+		return nil, errBadFrame
+	}
+	return payload, nil
+}
+
+// syncDir fsync 目录，保证重命名本身已经落盘
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,301 @@
+package Map
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openDurable(t *testing.T, dir string, opts DurableOptions) *DurableMap[string, int] {
+	t.Helper()
+	m, err := OpenDurableMap[string, int](dir, opts)
+	if err != nil {
+		t.Fatalf("OpenDurableMap: %v", err)
+	}
+	return m
+}
+
+// checkContents 校验 m 中的数据与 want 完全一致
+func checkContents(t *testing.T, m *DurableMap[string, int], want map[string]int) {
+	t.Helper()
+	if m.Len() != len(want) {
+		t.Fatalf("Len() = %d, want %d", m.Len(), len(want))
+	}
+	for k, v := range want {
+		if got, ok := m.Get(k); !ok || got != v {
+			t.Fatalf("Get(%q) = (%v, %v), want %v", k, got, ok, v)
+		}
+	}
+}
+
+func TestDurableMapReopen(t *testing.T) {
+	codecs := map[string]Codec{"gob": GobCodec{}, "json": JSONCodec{}}
+	policies := map[string]SyncPolicy{"always": SyncAlways, "interval": SyncInterval, "never": SyncNever}
+	for codecName, codec := range codecs {
+		for policyName, policy := range policies {
+			t.Run(codecName+"/"+policyName, func(t *testing.T) {
+				dir := t.TempDir()
+				opts := DurableOptions{Codec: codec, Sync: policy, SyncInterval: time.Millisecond}
+				m := openDurable(t, dir, opts)
+				want := make(map[string]int)
+				for i := 0; i < 100; i++ {
+					k := fmt.Sprint("k", i%30)
+					if i%7 == 0 {
+						m.Remove(k)
+						delete(want, k)
+					} else {
+						m.Put(k, i)
+						want[k] = i
+					}
+				}
+				if err := m.Close(); err != nil {
+					t.Fatalf("Close: %v", err)
+				}
+				if err := m.Put("x", 1); err != ErrClosed {
+					t.Fatalf("Put after Close = %v, want ErrClosed", err)
+				}
+
+				m = openDurable(t, dir, opts)
+				defer m.Close()
+				checkContents(t, m, want)
+				if r := m.Recovery(); r.SnapshotEntries != 0 || r.TruncatedBytes != 0 {
+					t.Fatalf("unexpected recovery info %+v", r)
+				}
+			})
+		}
+	}
+}
+
+func TestDurableMapSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	m := openDurable(t, dir, DurableOptions{SnapshotEvery: 10})
+	want := make(map[string]int)
+	for i := 0; i < 25; i++ {
+		k := fmt.Sprint("k", i%8)
+		m.Put(k, i)
+		want[k] = i
+	}
+	m.Close()
+
+	// 25 条记录触发了两次快照，日志中只剩 5 条
+	m = openDurable(t, dir, DurableOptions{SnapshotEvery: 10})
+	defer m.Close()
+	checkContents(t, m, want)
+	if r := m.Recovery(); r.SnapshotEntries != 8 || r.Replayed != 5 {
+		t.Fatalf("recovery = %+v, want 8 snapshot entries and 5 replayed records", r)
+	}
+
+	if err := m.Snapshot(); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if info, _ := os.Stat(filepath.Join(dir, walFile)); info.Size() != 0 {
+		t.Fatalf("wal size = %d after snapshot, want 0", info.Size())
+	}
+}
+
+func TestDurableMapSnapshotInterval(t *testing.T) {
+	dir := t.TempDir()
+	m := openDurable(t, dir, DurableOptions{Sync: SyncNever, SnapshotInterval: time.Millisecond})
+	m.Put("a", 1)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if info, err := os.Stat(filepath.Join(dir, snapshotFile)); err == nil && info.Size() > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no snapshot written by the background task")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	m.Close()
+}
+
+// writeLog 写入 n 条记录后关闭，返回日志文件路径
+func writeLog(t *testing.T, dir string, n int) string {
+	t.Helper()
+	m := openDurable(t, dir, DurableOptions{})
+	for i := 0; i < n; i++ {
+		m.Put(fmt.Sprint("k", i), i)
+	}
+	m.Close()
+	return filepath.Join(dir, walFile)
+}
+
+func TestDurableMapRecoverTail(t *testing.T) {
+	cases := map[string]func(t *testing.T, path string){
+		"truncated": func(t *testing.T, path string) {
+			info, _ := os.Stat(path)
+			os.Truncate(path, info.Size()-3)
+		},
+		"corrupt": func(t *testing.T, path string) {
+			data, _ := os.ReadFile(path)
+			data[len(data)-2] ^= 0xff
+			os.WriteFile(path, data, 0o644)
+		},
+		"header only": func(t *testing.T, path string) {
+			f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+			f.Write([]byte{9, 0, 0})
+			f.Close()
+		},
+		"zero filled": func(t *testing.T, path string) {
+			f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+			f.Write(make([]byte, 64))
+			f.Close()
+		},
+	}
+	for name, damage := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeLog(t, dir, 10)
+			damage(t, path)
+
+			m := openDurable(t, dir, DurableOptions{})
+			r := m.Recovery()
+			if r.TruncatedBytes == 0 {
+				t.Fatalf("damaged tail was not truncated: %+v", r)
+			}
+			// 截断或损坏最后一条记录时只丢失这一条，追加垃圾时不丢数据
+			for i := 0; i < r.Replayed; i++ {
+				if v, ok := m.Get(fmt.Sprint("k", i)); !ok || v != i {
+					t.Fatalf("Get(k%d) = (%v, %v) after recovery", i, v, ok)
+				}
+			}
+			if r.Replayed < 9 || m.Len() != r.Replayed {
+				t.Fatalf("replayed %d records, Len() = %d", r.Replayed, m.Len())
+			}
+
+			// 修复后的日志可以继续追加，再次打开时不再需要截断
+			m.Put("after", 1)
+			m.Close()
+			m = openDurable(t, dir, DurableOptions{})
+			defer m.Close()
+			if v, ok := m.Get("after"); !ok || v != 1 {
+				t.Fatalf("record written after recovery was lost")
+			}
+			if r := m.Recovery(); r.TruncatedBytes != 0 {
+				t.Fatalf("log still damaged after repair: %+v", r)
+			}
+		})
+	}
+}
+
+// TestDurableMapCrashAfterSnapshot 模拟快照重命名完成、日志尚未清空时宕机：日志在快照上重放的结果不变
+func TestDurableMapCrashAfterSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	m := openDurable(t, dir, DurableOptions{})
+	want := map[string]int{"a": 3, "c": 4}
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Put("a", 3)
+	m.Remove("b")
+	m.Put("c", 4)
+	log, err := os.ReadFile(filepath.Join(dir, walFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Snapshot(); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	m.Close()
+	os.WriteFile(filepath.Join(dir, walFile), log, 0o644)
+
+	m = openDurable(t, dir, DurableOptions{})
+	defer m.Close()
+	checkContents(t, m, want)
+}
+
+func TestDurableMapCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	m := openDurable(t, dir, DurableOptions{})
+	m.Put("a", 1)
+	m.Snapshot()
+	m.Close()
+
+	path := filepath.Join(dir, snapshotFile)
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 0xff
+	os.WriteFile(path, data, 0o644)
+	if _, err := OpenDurableMap[string, int](dir, DurableOptions{}); !errors.Is(err, ErrCorruptSnapshot) {
+		t.Fatalf("OpenDurableMap = %v, want ErrCorruptSnapshot", err)
+	}
+}
+
+// TestDurableMapWrongCodec 校验通过但无法解码的记录不能被当作损坏的尾部截掉
+func TestDurableMapWrongCodec(t *testing.T) {
+	dir := t.TempDir()
+	path := writeLog(t, dir, 3)
+	before, _ := os.Stat(path)
+	if _, err := OpenDurableMap[string, int](dir, DurableOptions{Codec: JSONCodec{}}); err == nil {
+		t.Fatalf("opening a gob log with the JSON codec should fail")
+	}
+	if after, _ := os.Stat(path); after.Size() != before.Size() {
+		t.Fatalf("log was truncated from %d to %d bytes", before.Size(), after.Size())
+	}
+}
+
+// TestDurableMapCorruptMiddle 中间的记录损坏时不能截掉后面完整的记录
+func TestDurableMapCorruptMiddle(t *testing.T) {
+	dir := t.TempDir()
+	path := writeLog(t, dir, 10)
+	data, _ := os.ReadFile(path)
+	data[frameHeaderSize+1] ^= 0xff // 第一条记录的内容
+	os.WriteFile(path, data, 0o644)
+
+	if _, err := OpenDurableMap[string, int](dir, DurableOptions{}); !errors.Is(err, ErrCorruptLog) {
+		t.Fatalf("OpenDurableMap = %v, want ErrCorruptLog", err)
+	}
+	if info, _ := os.Stat(path); info.Size() != int64(len(data)) {
+		t.Fatalf("log was truncated from %d to %d bytes", len(data), info.Size())
+	}
+}
+
+// TestDurableMapCorruptLength 中间一条记录的长度被改大，看起来延伸到文件末尾，后面的记录不能被截掉
+func TestDurableMapCorruptLength(t *testing.T) {
+	dir := t.TempDir()
+	path := writeLog(t, dir, 10)
+	data, _ := os.ReadFile(path)
+	data[3] = 0x10 // 第一条记录的长度变为 256MB 以上，仍然小于 maxFrameSize
+	os.WriteFile(path, data, 0o644)
+
+	if _, err := OpenDurableMap[string, int](dir, DurableOptions{}); !errors.Is(err, ErrCorruptLog) {
+		t.Fatalf("OpenDurableMap = %v, want ErrCorruptLog", err)
+	}
+	if info, _ := os.Stat(path); info.Size() != int64(len(data)) {
+		t.Fatalf("log was truncated from %d to %d bytes", len(data), info.Size())
+	}
+}
+
+// failingSync fsync 总是失败的日志文件
+type failingSync struct {
+	*os.File
+}
+
+func (f failingSync) Sync() error { return errors.New("sync failed") }
+
+// TestDurableMapSyncFailure fsync 失败的写入返回错误，重启后也不能出现
+func TestDurableMapSyncFailure(t *testing.T) {
+	dir := t.TempDir()
+	m := openDurable(t, dir, DurableOptions{})
+	m.Put("a", 1)
+	file := m.wal.(*os.File)
+	m.wal = failingSync{file}
+	if err := m.Put("b", 2); err == nil {
+		t.Fatal("Put should fail when fsync fails")
+	}
+	if _, ok := m.Get("b"); ok {
+		t.Fatal("failed Put changed the map")
+	}
+	m.wal = file
+	m.Put("c", 3)
+	m.Close()
+
+	m = openDurable(t, dir, DurableOptions{})
+	defer m.Close()
+	checkContents(t, m, map[string]int{"a": 1, "c": 3})
+	if r := m.Recovery(); r.TruncatedBytes != 0 || r.Replayed != 2 {
+		t.Fatalf("recovery = %+v", r)
+	}
+}
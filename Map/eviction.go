@@ -0,0 +1,145 @@
+package Map
+
+import (
+	"container/list"
+	"math/rand"
+
+	"bash_algorithm/internal/lfu"
+)
+
+// EvictionPolicy BoundedMap 满了之后选择淘汰对象的策略
+type EvictionPolicy int
+
+const (
+	PolicyLRU    EvictionPolicy = iota // 淘汰最久没有访问的
+	PolicyFIFO                         // 淘汰最早写入的，访问不影响顺序
+	PolicyRandom                       // 随机淘汰
+	PolicyLFU                          // 淘汰访问次数最少的，次数相同时淘汰最久没有访问的
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case PolicyLRU:
+		return "lru"
+	case PolicyFIFO:
+		return "fifo"
+	case PolicyRandom:
+		return "random"
+	case PolicyLFU:
+		return "lfu"
+	default:
+		return "unknown"
+	}
+}
+
+// evictionPolicy 记录 key 的访问情况并选出淘汰对象，调用方负责加锁
+type evictionPolicy[K comparable] interface {
+	add(key K)    // 写入新 key
+	access(key K) // 读取或更新已有的 key
+	remove(key K) // key 被删除或淘汰
+	// victim 返回下一个应该淘汰的 key，不会返回 skip（正在写入的 key）
+	victim(skip K) (K, bool)
+}
+
+func newEvictionPolicy[K comparable](p EvictionPolicy) evictionPolicy[K] {
+	switch p {
+	case PolicyFIFO:
+		return &listPolicy[K]{items: make(map[K]*list.Element), order: list.New()}
+	case PolicyRandom:
+		return &randomPolicy[K]{index: make(map[K]int)}
+	case PolicyLFU:
+		return &lfuPolicy[K]{counts: lfu.New[K]()}
+	default:
+		return &listPolicy[K]{items: make(map[K]*list.Element), order: list.New(), lru: true}
+	}
+}
+
+// listPolicy 用链表维护顺序，队首是最新的。lru 为 true 时访问会把 key 移到队首，否则为 FIFO
+type listPolicy[K comparable] struct {
+	items map[K]*list.Element
+	order *list.List
+	lru   bool
+}
+
+func (p *listPolicy[K]) add(key K) {
+	p.items[key] = p.order.PushFront(key)
+}
+
+func (p *listPolicy[K]) access(key K) {
+	if p.lru {
+		p.order.MoveToFront(p.items[key])
+	}
+}
+
+func (p *listPolicy[K]) remove(key K) {
+	if e, ok := p.items[key]; ok {
+		p.order.Remove(e)
+		delete(p.items, key)
+	}
+}
+
+func (p *listPolicy[K]) victim(skip K) (K, bool) {
+	return backSkipping(p.order, skip, func(v any) K { return v.(K) })
+}
+
+// randomPolicy 用切片保存所有 key，删除时把最后一个元素换到被删除的位置
+type randomPolicy[K comparable] struct {
+	keys  []K
+	index map[K]int
+}
+
+func (p *randomPolicy[K]) add(key K) {
+	p.index[key] = len(p.keys)
+	p.keys = append(p.keys, key)
+}
+
+func (p *randomPolicy[K]) access(K) {}
+
+func (p *randomPolicy[K]) remove(key K) {
+	i, ok := p.index[key]
+	if !ok {
+		return
+	}
+	last := len(p.keys) - 1
+	p.keys[i] = p.keys[last]
+	p.index[p.keys[i]] = i
+	p.keys = p.keys[:last]
+	delete(p.index, key)
+}
+
+func (p *randomPolicy[K]) victim(skip K) (K, bool) {
+	n := len(p.keys)
+	if n == 0 || (n == 1 && p.keys[0] == skip) {
+		var zero K
+		return zero, false
+	}
+	i := rand.Intn(n)
+	if p.keys[i] == skip {
+		i = (i + 1) % n
+	}
+	return p.keys[i], true
+}
+
+// lfuPolicy 淘汰访问次数最少的 key，次数相同时淘汰最久没有访问的，访问记录由 lfu.LFU 维护
+type lfuPolicy[K comparable] struct {
+	counts *lfu.LFU[K]
+}
+
+func (p *lfuPolicy[K]) add(key K)    { p.counts.Add(key) }
+func (p *lfuPolicy[K]) access(key K) { p.counts.Access(key) }
+func (p *lfuPolicy[K]) remove(key K) { p.counts.Remove(key) }
+
+func (p *lfuPolicy[K]) victim(skip K) (K, bool) {
+	return p.counts.VictimExcept(skip)
+}
+
+// backSkipping 返回链表末尾第一个不等于 skip 的 key
+func backSkipping[K comparable](l *list.List, skip K, keyOf func(v any) K) (K, bool) {
+	for e := l.Back(); e != nil; e = e.Prev() {
+		if k := keyOf(e.Value); k != skip {
+			return k, true
+		}
+	}
+	var zero K
+	return zero, false
+}
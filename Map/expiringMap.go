@@ -0,0 +1,263 @@
+package Map
+
+import (
+	"sync"
+	"time"
+)
+
+// EvictReason 条目离开 ExpiringMap 的原因
+type EvictReason int
+
+const (
+	EvictExpired  EvictReason = iota // 过期后被读取时或被后台清理时移除
+	EvictRemoved                     // 被 Remove 主动删除
+	EvictReplaced                    // 被 Put 写入的新值覆盖
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictExpired:
+		return "expired"
+	case EvictRemoved:
+		return "removed"
+	case EvictReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
+// expiringEntry 带过期时间的条目，expireAt 为零值表示永不过期
+type expiringEntry[V any] struct {
+	value    V
+	ttl      time.Duration
+	expireAt time.Time
+}
+
+func (e expiringEntry[V]) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// ExpiringMap 条目会过期的并发 map，底层使用 SharedMap 分片加锁。
+// 过期的条目在读取时惰性删除，同时后台清理协程按固定间隔扫描并删除过期条目
+type ExpiringMap[K comparable, V any] struct {
+	items      *SharedMap[K, expiringEntry[V]]
+	defaultTTL time.Duration
+	onEvict    func(key K, value V, reason EvictReason) // 条目离开时的回调，在分片锁外调用
+
+	now      func() time.Time // 时间来源，默认 time.Now，测试中可替换为虚拟时钟
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewExpiringMap 创建 ExpiringMap。
+// defaultTTL 为 Put 使用的默认存活时间，小于等于 0 表示永不过期；
+// cleanupInterval 为后台清理的间隔，小于等于 0 时不启动后台清理，只依赖读取时的惰性删除；
+// onEvict 可以为 nil
+func NewExpiringMap[K comparable, V any](defaultTTL, cleanupInterval time.Duration,
+	onEvict func(key K, value V, reason EvictReason)) *ExpiringMap[K, V] {
+	return newExpiringMap(defaultTTL, cleanupInterval, onEvict, time.Now)
+}
+
+// newExpiringMap 使用指定的时间来源创建 ExpiringMap，时间来源必须在后台清理协程启动前确定
+func newExpiringMap[K comparable, V any](defaultTTL, cleanupInterval time.Duration,
+	onEvict func(key K, value V, reason EvictReason), now func() time.Time) *ExpiringMap[K, V] {
+	m := &ExpiringMap[K, V]{
+		items:      NewSharedMap[K, expiringEntry[V]](DefaultShardCount, nil),
+		defaultTTL: defaultTTL,
+		onEvict:    onEvict,
+		now:        now,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if cleanupInterval > 0 {
+		go m.janitor(cleanupInterval)
+	} else {
+		close(m.done)
+	}
+	return m
+}
+
+// Put 使用默认存活时间写入键值对
+func (m *ExpiringMap[K, V]) Put(key K, value V) {
+	m.PutWithTTL(key, value, m.defaultTTL)
+}
+
+// PutWithTTL 使用指定的存活时间写入键值对，ttl 小于等于 0 表示永不过期
+func (m *ExpiringMap[K, V]) PutWithTTL(key K, value V, ttl time.Duration) {
+	entry := m.newEntry(value, ttl)
+	var old expiringEntry[V]
+	var replaced bool
+	m.items.Compute(key, func(prev expiringEntry[V], loaded bool) (expiringEntry[V], bool) {
+		old, replaced = prev, loaded
+		return entry, true
+	})
+	if replaced {
+		// 被覆盖的旧值如果已经过期，按过期处理
+		if old.expired(m.now()) {
+			m.evicted(key, old.value, EvictExpired)
+		} else {
+			m.evicted(key, old.value, EvictReplaced)
+		}
+	}
+}
+
+// Get 返回 key 对应的值，已过期的条目视为不存在并被删除
+func (m *ExpiringMap[K, V]) Get(key K) (V, bool) {
+	entry, ok := m.items.Get(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	if entry.expired(m.now()) {
+		m.expire(key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Remove 删除 key，返回 key 是否存在且未过期
+func (m *ExpiringMap[K, V]) Remove(key K) bool {
+	entry, ok := m.items.LoadAndDelete(key)
+	if !ok {
+		return false
+	}
+	if entry.expired(m.now()) {
+		m.evicted(key, entry.value, EvictExpired)
+		return false
+	}
+	m.evicted(key, entry.value, EvictRemoved)
+	return true
+}
+
+// Touch 将 key 的过期时间从现在起重新延长一个存活时间，返回 key 是否存在且未过期
+func (m *ExpiringMap[K, V]) Touch(key K) bool {
+	return m.TouchWithTTL(key, 0)
+}
+
+// TouchWithTTL 将 key 的过期时间设置为从现在起 ttl 之后，ttl 小于等于 0 时沿用条目原来的存活时间。
+// 返回 key 是否存在且未过期
+func (m *ExpiringMap[K, V]) TouchWithTTL(key K, ttl time.Duration) bool {
+	now := m.now()
+	touched := false
+	m.items.Update(key, func(entry expiringEntry[V]) expiringEntry[V] {
+		if entry.expired(now) {
+			return entry
+		}
+		touched = true
+		if ttl <= 0 {
+			ttl = entry.ttl
+		}
+		return m.newEntry(entry.value, ttl)
+	})
+	if !touched {
+		m.expire(key)
+	}
+	return touched
+}
+
+// TTL 返回 key 的剩余存活时间，永不过期的条目返回 0
+func (m *ExpiringMap[K, V]) TTL(key K) (time.Duration, bool) {
+	entry, ok := m.items.Get(key)
+	now := m.now()
+	if !ok || entry.expired(now) {
+		return 0, false
+	}
+	if entry.expireAt.IsZero() {
+		return 0, true
+	}
+	return entry.expireAt.Sub(now), true
+}
+
+// Len 返回条目数量，包含已经过期但还没有被清理的条目
+func (m *ExpiringMap[K, V]) Len() int {
+	return m.items.Len()
+}
+
+// Range 遍历所有未过期的条目，f 返回 false 时停止遍历
+func (m *ExpiringMap[K, V]) Range(f func(key K, value V) bool) {
+	now := m.now()
+	m.items.Range(func(key K, entry expiringEntry[V]) bool {
+		if entry.expired(now) {
+			return true
+		}
+		return f(key, entry.value)
+	})
+}
+
+// DeleteExpired 立即扫描并删除所有过期的条目，返回删除的数量
+func (m *ExpiringMap[K, V]) DeleteExpired() int {
+	now := m.now()
+	var expired []K
+	m.items.Range(func(key K, entry expiringEntry[V]) bool {
+		if entry.expired(now) {
+			expired = append(expired, key)
+		}
+		return true
+	})
+	n := 0
+	for _, key := range expired {
+		if m.expire(key) {
+			n++
+		}
+	}
+	return n
+}
+
+// Close 停止后台清理协程并等待其退出，可以重复调用
+func (m *ExpiringMap[K, V]) Close() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	<-m.done
+}
+
+// janitor 后台清理协程
+func (m *ExpiringMap[K, V]) janitor(interval time.Duration) {
+	defer close(m.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.DeleteExpired()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// expire 在分片锁内再次确认条目已过期后删除，避免删除掉并发写入的新值
+func (m *ExpiringMap[K, V]) expire(key K) bool {
+	now := m.now()
+	var old expiringEntry[V]
+	removed := false
+	m.items.Compute(key, func(entry expiringEntry[V], loaded bool) (expiringEntry[V], bool) {
+		if !loaded {
+			return entry, false
+		}
+		if entry.expired(now) {
+			old, removed = entry, true
+			return entry, false
+		}
+		return entry, true
+	})
+	if removed {
+		m.evicted(key, old.value, EvictExpired)
+	}
+	return removed
+}
+
+func (m *ExpiringMap[K, V]) newEntry(value V, ttl time.Duration) expiringEntry[V] {
+	entry := expiringEntry[V]{value: value, ttl: ttl}
+	if ttl > 0 {
+		entry.expireAt = m.now().Add(ttl)
+	}
+	return entry
+}
+
+func (m *ExpiringMap[K, V]) evicted(key K, value V, reason EvictReason) {
+	if m.onEvict != nil {
+		m.onEvict(key, value, reason)
+	}
+}
@@ -0,0 +1,161 @@
+package Map
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock 可以手动推进的时钟
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type evictEvent struct {
+	key    string
+	value  int
+	reason EvictReason
+}
+
+// evictRecorder 记录 ExpiringMap 的淘汰回调
+type evictRecorder struct {
+	mu     sync.Mutex
+	events []evictEvent
+}
+
+func (r *evictRecorder) record(key string, value int, reason EvictReason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, evictEvent{key, value, reason})
+}
+
+func (r *evictRecorder) snapshot() []evictEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]evictEvent(nil), r.events...)
+}
+
+func TestExpiringMap(t *testing.T) {
+	clock := newTestClock()
+	rec := &evictRecorder{}
+	m := newExpiringMap[string, int](time.Minute, 0, rec.record, clock.Now)
+	defer m.Close()
+
+	m.Put("default", 1)
+	m.PutWithTTL("short", 2, time.Second)
+	m.PutWithTTL("forever", 3, 0)
+
+	clock.Advance(2 * time.Second)
+	if _, ok := m.Get("short"); ok {
+		t.Fatalf("short should have expired")
+	}
+	if v, ok := m.Get("default"); !ok || v != 1 {
+		t.Fatalf("Get(default) = (%v, %v)", v, ok)
+	}
+
+	// Touch 从现在起重新计算存活时间
+	clock.Advance(50 * time.Second)
+	if !m.Touch("default") {
+		t.Fatalf("Touch(default) failed")
+	}
+	clock.Advance(30 * time.Second)
+	if _, ok := m.Get("default"); !ok {
+		t.Fatalf("default expired although it was touched")
+	}
+	if ttl, ok := m.TTL("default"); !ok || ttl != 30*time.Second {
+		t.Fatalf("TTL(default) = (%v, %v), want 30s", ttl, ok)
+	}
+
+	m.Put("default", 10)
+	if !m.Remove("default") {
+		t.Fatalf("Remove(default) failed")
+	}
+	clock.Advance(time.Hour)
+	if _, ok := m.Get("forever"); !ok {
+		t.Fatalf("entry without ttl expired")
+	}
+
+	want := []evictEvent{
+		{"short", 2, EvictExpired},
+		{"default", 1, EvictReplaced},
+		{"default", 10, EvictRemoved},
+	}
+	got := rec.snapshot()
+	if len(got) != len(want) {
+		t.Fatalf("evictions = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("eviction %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExpiringMapJanitor(t *testing.T) {
+	clock := newTestClock()
+	rec := &evictRecorder{}
+	m := newExpiringMap[string, int](time.Second, time.Millisecond, rec.record, clock.Now)
+
+	for i := 0; i < 100; i++ {
+		m.Put(string(rune('a'+i%26))+string(rune('a'+i/26)), i)
+	}
+	m.PutWithTTL("keep", -1, time.Hour)
+	clock.Advance(2 * time.Second)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor did not remove expired entries, Len() = %d", m.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	m.Close()
+	m.Close()
+
+	for _, e := range rec.snapshot() {
+		if e.reason != EvictExpired {
+			t.Fatalf("unexpected eviction %v", e)
+		}
+	}
+	if n := len(rec.snapshot()); n != 100 {
+		t.Fatalf("%d expired callbacks, want 100", n)
+	}
+}
+
+func TestExpiringMapConcurrent(t *testing.T) {
+	m := NewExpiringMap[int, int](time.Millisecond, time.Millisecond, nil)
+	defer m.Close()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				m.Put(i%64, g)
+				m.Get(i % 64)
+				m.Touch(i % 64)
+				if i%10 == 0 {
+					m.Remove(i % 64)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,254 @@
+package Map
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// LockFreeMap 基于 split-ordered list 的无锁哈希表，适合读多写少的热点路径。
+//
+// 所有节点按 split-order（哈希值按位反转后的顺序）串在同一条无锁有序链表上，
+// 每个桶只是指向链表中某个哨兵节点的"快捷入口"。扩容时桶数量翻倍，
+// 新桶的哨兵节点按需插入到父桶的区间中，已有节点不需要移动。
+//
+// 链表使用 Harris-Michael 算法：删除时先把节点的值替换为墓碑（线性化点），
+// 再把 next 标记为已删除，最后由删除者或后续遍历者把节点从链表中摘除。
+type LockFreeMap[K comparable, V any] struct {
+	head    *lfNode[K, V]                                  // 0 号桶的哨兵节点，也是整条链表的头
+	buckets atomic.Pointer[[]atomic.Pointer[lfNode[K, V]]] // 桶 -> 哨兵节点，nil 表示尚未初始化
+	count   atomic.Int64
+	hash    HashFunc[K]
+	tomb    *lfBox[V] // 墓碑，节点的值替换为它表示节点已被逻辑删除
+}
+
+const (
+	lockFreeInitBuckets = 16
+	lockFreeMaxBuckets  = 1 << 24
+	lockFreeLoadFactor  = 2 // 平均每个桶的元素数量超过该值时扩容
+)
+
+// lfNode 链表节点，哨兵节点的 soKey 最低位为 0，普通节点为 1
+type lfNode[K comparable, V any] struct {
+	soKey    uint64
+	sentinel bool
+	key      K
+	value    atomic.Pointer[lfBox[V]]
+	next     atomic.Pointer[lfLink[K, V]]
+}
+
+// lfBox 节点的值，每次写入都替换整个 lfBox 以便 CAS
+type lfBox[V any] struct {
+	v       V
+	deleted bool // 墓碑
+}
+
+// lfLink 不可变的 next 指针，marked 表示持有它的节点已经被删除。
+// Go 中无法在指针上打标记位，因此每次修改都替换整个 lfLink
+type lfLink[K comparable, V any] struct {
+	node   *lfNode[K, V]
+	marked bool
+}
+
+// NewLockFreeMap 创建 LockFreeMap，hash 为 nil 时使用 FNVHash
+func NewLockFreeMap[K comparable, V any](hash HashFunc[K]) *LockFreeMap[K, V] {
+	if hash == nil {
+		hash = FNVHash[K]
+	}
+	m := &LockFreeMap[K, V]{hash: hash, tomb: &lfBox[V]{deleted: true}}
+	m.head = &lfNode[K, V]{sentinel: true}
+	m.head.next.Store(&lfLink[K, V]{})
+	buckets := make([]atomic.Pointer[lfNode[K, V]], lockFreeInitBuckets)
+	buckets[0].Store(m.head)
+	m.buckets.Store(&buckets)
+	return m
+}
+
+// Get 返回 key 对应的值以及 key 是否存在，不修改任何键值对；
+// 所在的桶尚未初始化时会用 CAS 插入哨兵节点
+func (m *LockFreeMap[K, V]) Get(key K) (V, bool) {
+	h := m.hash(key)
+	soKey := regularKey(h)
+	for curr := m.bucket(h).next.Load().node; curr != nil && curr.soKey <= soKey; curr = curr.next.Load().node {
+		if curr.soKey == soKey && curr.key == key {
+			if v := curr.value.Load(); !v.deleted {
+				return v.v, true
+			}
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Put 写入键值对，key 已存在时原子地替换其值
+func (m *LockFreeMap[K, V]) Put(key K, value V) {
+	h := m.hash(key)
+	start := m.bucket(h)
+	v := &lfBox[V]{v: value}
+	for {
+		pred, predLink, curr, found := m.find(start, regularKey(h), key, false)
+		if found {
+			old := curr.value.Load()
+			if !old.deleted && curr.value.CompareAndSwap(old, v) {
+				return
+			}
+			// 节点被并发删除，重新查找
+			continue
+		}
+		node := &lfNode[K, V]{soKey: regularKey(h), key: key}
+		node.value.Store(v)
+		node.next.Store(&lfLink[K, V]{node: curr})
+		if pred.next.CompareAndSwap(predLink, &lfLink[K, V]{node: node}) {
+			m.grow(m.count.Add(1))
+			return
+		}
+	}
+}
+
+// Delete 删除 key，返回删除前 key 是否存在
+func (m *LockFreeMap[K, V]) Delete(key K) bool {
+	_, ok := m.LoadAndDelete(key)
+	return ok
+}
+
+// LoadAndDelete 删除 key 并返回删除前的值，loaded 表示 key 是否存在
+func (m *LockFreeMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	h := m.hash(key)
+	start := m.bucket(h)
+	for {
+		_, _, curr, found := m.find(start, regularKey(h), key, false)
+		if !found {
+			return value, false
+		}
+		old := curr.value.Load()
+		if old.deleted {
+			continue
+		}
+		// 把值替换为墓碑是删除的线性化点，之后再标记并摘除节点
+		if curr.value.CompareAndSwap(old, m.tomb) {
+			m.count.Add(-1)
+			m.mark(curr)
+			m.find(start, regularKey(h), key, false)
+			return old.v, true
+		}
+	}
+}
+
+// Len 返回元素数量
+func (m *LockFreeMap[K, V]) Len() int {
+	return int(m.count.Load())
+}
+
+// Range 按链表顺序遍历所有键值对，f 返回 false 时停止遍历。
+// 遍历是弱一致的：不会重复访问同一个 key，但可能看不到遍历开始后的修改
+func (m *LockFreeMap[K, V]) Range(f func(key K, value V) bool) {
+	for curr := m.head.next.Load().node; curr != nil; curr = curr.next.Load().node {
+		if curr.sentinel {
+			continue
+		}
+		if v := curr.value.Load(); !v.deleted {
+			if !f(curr.key, v.v) {
+				return
+			}
+		}
+	}
+}
+
+// find 从 start 开始查找 soKey 和 key 对应的节点，返回插入位置 pred -> curr 以及是否找到。
+// 途中遇到已删除的节点会顺手摘除，遇到值为墓碑但还没标记的节点会先帮忙标记
+func (m *LockFreeMap[K, V]) find(start *lfNode[K, V], soKey uint64, key K, sentinel bool) (
+	pred *lfNode[K, V], predLink *lfLink[K, V], curr *lfNode[K, V], found bool) {
+retry:
+	pred = start
+	predLink = pred.next.Load()
+	curr = predLink.node
+	for curr != nil {
+		currLink := curr.next.Load()
+		if currLink.marked {
+			// curr 已删除，把它从 pred 后面摘除；pred 本身被删除时 CAS 会失败，从头再来
+			unlinked := &lfLink[K, V]{node: currLink.node}
+			if !pred.next.CompareAndSwap(predLink, unlinked) {
+				goto retry
+			}
+			predLink = unlinked
+			curr = currLink.node
+			continue
+		}
+		if curr.soKey > soKey {
+			return pred, predLink, curr, false
+		}
+		if curr.soKey == soKey && curr.sentinel == sentinel && (sentinel || curr.key == key) {
+			if sentinel || !curr.value.Load().deleted {
+				return pred, predLink, curr, true
+			}
+			// 值已经是墓碑，帮助删除者完成标记后重新查找
+			m.mark(curr)
+			goto retry
+		}
+		pred, predLink, curr = curr, currLink, currLink.node
+	}
+	return pred, predLink, nil, false
+}
+
+// mark 把节点的 next 标记为已删除
+func (m *LockFreeMap[K, V]) mark(node *lfNode[K, V]) {
+	for {
+		link := node.next.Load()
+		if link.marked || node.next.CompareAndSwap(link, &lfLink[K, V]{node: link.node, marked: true}) {
+			return
+		}
+	}
+}
+
+// bucket 返回哈希值 h 所在桶的哨兵节点，桶尚未初始化时先初始化
+func (m *LockFreeMap[K, V]) bucket(h uint64) *lfNode[K, V] {
+	buckets := *m.buckets.Load()
+	return m.initBucket(buckets, h&uint64(len(buckets)-1))
+}
+
+// initBucket 返回 b 号桶的哨兵节点。哨兵节点插入在父桶（去掉 b 的最高位）的区间内，
+// 父桶未初始化时递归初始化。多个协程同时初始化同一个桶时只有一个哨兵会被插入
+func (m *LockFreeMap[K, V]) initBucket(buckets []atomic.Pointer[lfNode[K, V]], b uint64) *lfNode[K, V] {
+	if s := buckets[b].Load(); s != nil {
+		return s
+	}
+	parent := m.initBucket(buckets, b&^(1<<(bits.Len64(b)-1)))
+	soKey := sentinelKey(b)
+	for {
+		pred, predLink, curr, found := m.find(parent, soKey, *new(K), true)
+		if found {
+			buckets[b].Store(curr)
+			return curr
+		}
+		s := &lfNode[K, V]{soKey: soKey, sentinel: true}
+		s.next.Store(&lfLink[K, V]{node: curr})
+		if pred.next.CompareAndSwap(predLink, &lfLink[K, V]{node: s}) {
+			buckets[b].Store(s)
+			return s
+		}
+	}
+}
+
+// grow 元素数量超过负载因子时把桶数量翻倍。
+// 新桶表复制已经初始化的哨兵指针，复制之后才初始化的桶会在新表中重新查找到同一个哨兵
+func (m *LockFreeMap[K, V]) grow(count int64) {
+	old := m.buckets.Load()
+	size := len(*old)
+	if count <= int64(size*lockFreeLoadFactor) || size >= lockFreeMaxBuckets {
+		return
+	}
+	buckets := make([]atomic.Pointer[lfNode[K, V]], size*2)
+	for i := range *old {
+		buckets[i].Store((*old)[i].Load())
+	}
+	m.buckets.CompareAndSwap(old, &buckets)
+}
+
+// regularKey 普通节点的 split-order key：置最高位后按位反转，反转后最低位为 1
+func regularKey(h uint64) uint64 {
+	return bits.Reverse64(h | 1<<63)
+}
+
+// sentinelKey 哨兵节点的 split-order key：桶号按位反转，最低位为 0
+func sentinelKey(b uint64) uint64 {
+	return bits.Reverse64(b)
+}
@@ -0,0 +1,273 @@
+package Map
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLockFreeMap(t *testing.T) {
+	m := NewLockFreeMap[int, string](nil)
+	for i := 0; i < 1000; i++ {
+		m.Put(i, fmt.Sprint(i))
+	}
+	if m.Len() != 1000 {
+		t.Fatalf("Len() = %d, want 1000", m.Len())
+	}
+	for i := 0; i < 1000; i++ {
+		if v, ok := m.Get(i); !ok || v != fmt.Sprint(i) {
+			t.Fatalf("Get(%d) = (%q, %v)", i, v, ok)
+		}
+	}
+	m.Put(7, "seven")
+	if v, _ := m.Get(7); v != "seven" {
+		t.Fatalf("Put did not replace the value, got %q", v)
+	}
+	if !m.Delete(7) || m.Delete(7) {
+		t.Fatalf("Delete should report whether the key existed")
+	}
+	if _, ok := m.Get(7); ok {
+		t.Fatalf("key 7 still present after Delete")
+	}
+	if v, ok := m.LoadAndDelete(8); !ok || v != "8" {
+		t.Fatalf("LoadAndDelete(8) = (%q, %v)", v, ok)
+	}
+
+	seen := make(map[int]bool)
+	m.Range(func(key int, value string) bool {
+		if seen[key] {
+			t.Fatalf("Range visited key %d twice", key)
+		}
+		seen[key] = true
+		return true
+	})
+	if len(seen) != 998 || m.Len() != 998 {
+		t.Fatalf("Range visited %d keys, Len() = %d, want 998", len(seen), m.Len())
+	}
+
+	// 所有 key 哈希冲突时仍然要能区分
+	collide := NewLockFreeMap[string, int](func(string) uint64 { return 42 })
+	for i := 0; i < 50; i++ {
+		collide.Put(fmt.Sprint(i), i)
+	}
+	collide.Delete("10")
+	for i := 0; i < 50; i++ {
+		if v, ok := collide.Get(fmt.Sprint(i)); ok != (i != 10) || (ok && v != i) {
+			t.Fatalf("colliding Get(%d) = (%v, %v)", i, v, ok)
+		}
+	}
+}
+
+// TestLockFreeMapStress 在 -race 下并发读写删除，结束后校验每个 key 的最终状态
+func TestLockFreeMapStress(t *testing.T) {
+	m := NewLockFreeMap[int, int](nil)
+	const workers, keys = 8, 4096
+	var wg, random sync.WaitGroup
+	random.Add(workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < 20000; i++ {
+				key := r.Intn(keys)
+				switch r.Intn(4) {
+				case 0:
+					m.Delete(key)
+				case 1:
+					m.Get(key)
+				default:
+					m.Put(key, key)
+				}
+			}
+			// 所有协程的随机阶段结束后，每个协程写入自己负责的 key，保证最终状态确定
+			random.Done()
+			random.Wait()
+			for key := w; key < keys; key += workers {
+				m.Put(key, -key)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if m.Len() != keys {
+		t.Fatalf("Len() = %d, want %d", m.Len(), keys)
+	}
+	for key := 0; key < keys; key++ {
+		if v, ok := m.Get(key); !ok || v != -key {
+			t.Fatalf("Get(%d) = (%v, %v), want %d", key, v, ok, -key)
+		}
+	}
+}
+
+// 以下是基于 Wing & Gong 算法的线性一致性检查器。
+// map 上不同 key 的操作互不影响，线性一致性可以按 key 分别检查
+
+type histKind int
+
+const (
+	histPut histKind = iota
+	histGet
+	histDelete
+)
+
+// histOp 一次操作的记录，call 和 ret 是调用开始和返回时的逻辑时间
+type histOp struct {
+	kind      histKind
+	value     int  // Put 的参数或 Get 的返回值
+	ok        bool // Get 和 Delete 的返回值
+	call, ret int64
+}
+
+func (o histOp) String() string {
+	switch o.kind {
+	case histPut:
+		return fmt.Sprintf("put(%d)@[%d,%d]", o.value, o.call, o.ret)
+	case histGet:
+		return fmt.Sprintf("get->(%d,%v)@[%d,%d]", o.value, o.ok, o.call, o.ret)
+	default:
+		return fmt.Sprintf("delete->%v@[%d,%d]", o.ok, o.call, o.ret)
+	}
+}
+
+// regState 单个 key 的顺序规约：存在与否以及当前值
+type regState struct {
+	value   int
+	present bool
+}
+
+// step 在状态 s 上顺序执行 o，返回新状态以及 o 的返回值是否与规约一致
+func (o histOp) step(s regState) (regState, bool) {
+	switch o.kind {
+	case histPut:
+		return regState{value: o.value, present: true}, true
+	case histGet:
+		if o.ok {
+			return s, s.present && s.value == o.value
+		}
+		return s, !s.present
+	default:
+		return regState{}, o.ok == s.present
+	}
+}
+
+// linearizable 检查单个 key 的操作历史是否存在合法的线性化顺序
+func linearizable(ops []histOp) bool {
+	sort.Slice(ops, func(i, j int) bool { return ops[i].call < ops[j].call })
+	done := make([]bool, len(ops))
+	failed := make(map[string]bool) // 已经证明无解的 (已线性化集合, 状态)
+
+	var search func(s regState, remaining int) bool
+	search = func(s regState, remaining int) bool {
+		if remaining == 0 {
+			return true
+		}
+		var b strings.Builder
+		for _, d := range done {
+			if d {
+				b.WriteByte('1')
+			} else {
+				b.WriteByte('0')
+			}
+		}
+		memo := fmt.Sprintf("%s|%d|%v", b.String(), s.value, s.present)
+		if failed[memo] {
+			return false
+		}
+
+		// 只有调用时间早于所有未线性化操作最早返回时间的操作才能排在下一个
+		minRet := int64(1<<63 - 1)
+		for i, o := range ops {
+			if !done[i] && o.ret < minRet {
+				minRet = o.ret
+			}
+		}
+		for i, o := range ops {
+			if done[i] || o.call > minRet {
+				continue
+			}
+			if next, ok := o.step(s); ok {
+				done[i] = true
+				if search(next, remaining-1) {
+					return true
+				}
+				done[i] = false
+			}
+		}
+		failed[memo] = true
+		return false
+	}
+	return search(regState{}, len(ops))
+}
+
+func TestLinearizabilityChecker(t *testing.T) {
+	ok := []histOp{
+		{kind: histPut, value: 1, call: 1, ret: 4},
+		{kind: histGet, value: 1, ok: true, call: 2, ret: 3},
+		{kind: histDelete, ok: true, call: 5, ret: 6},
+		{kind: histGet, ok: false, call: 7, ret: 8},
+	}
+	if !linearizable(ok) {
+		t.Fatalf("valid history rejected: %v", ok)
+	}
+	stale := []histOp{
+		{kind: histPut, value: 1, call: 1, ret: 2},
+		{kind: histPut, value: 2, call: 3, ret: 4},
+		{kind: histGet, value: 1, ok: true, call: 5, ret: 6},
+	}
+	if linearizable(stale) {
+		t.Fatalf("stale read accepted: %v", stale)
+	}
+}
+
+// TestLockFreeMapLinearizable 并发执行随机操作并记录历史，逐个 key 检查线性一致性
+func TestLockFreeMapLinearizable(t *testing.T) {
+	const workers, keys, opsPerWorker = 4, 6, 60
+	for round := 0; round < 20; round++ {
+		m := NewLockFreeMap[int, int](nil)
+		var clock int64
+		histories := make([]map[int][]histOp, workers)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(int64(round*workers + w)))
+				local := make(map[int][]histOp)
+				for i := 0; i < opsPerWorker; i++ {
+					key := r.Intn(keys)
+					o := histOp{call: atomic.AddInt64(&clock, 1)}
+					switch r.Intn(3) {
+					case 0:
+						o.kind, o.value = histPut, w*opsPerWorker+i+1
+						m.Put(key, o.value)
+					case 1:
+						o.kind = histGet
+						o.value, o.ok = m.Get(key)
+					default:
+						o.kind = histDelete
+						o.ok = m.Delete(key)
+					}
+					o.ret = atomic.AddInt64(&clock, 1)
+					local[key] = append(local[key], o)
+				}
+				histories[w] = local
+			}(w)
+		}
+		wg.Wait()
+
+		for key := 0; key < keys; key++ {
+			var ops []histOp
+			for _, h := range histories {
+				ops = append(ops, h[key]...)
+			}
+			if !linearizable(ops) {
+				t.Fatalf("round %d key %d: history is not linearizable: %v", round, key, ops)
+			}
+		}
+	}
+}
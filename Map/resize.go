@@ -0,0 +1,97 @@
+package Map
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+)
+
+// ErrInvalidShardCount 扩缩容时分片数量不合法
+var ErrInvalidShardCount = errors.New("shard count must be greater than 0")
+
+// tableState SharedMap 当前使用的分片表。
+// 扩缩容期间 old 指向旧表，cur 指向新表；旧表中的分片按需逐个迁移到新表，
+// 读写操作在访问 key 前先迁移 key 所在的旧分片，因此不会出现全局停顿
+type tableState[K comparable, V any] struct {
+	cur      *shardTable[K, V]
+	old      *shardTable[K, V] // nil 表示没有进行中的扩缩容
+	migrated atomic.Int64      // 旧表中已经迁移完成的分片数量
+}
+
+// ResizeProgress 扩缩容的进度
+type ResizeProgress struct {
+	Resizing bool // 是否正在扩缩容
+	From     int  // 旧的分片数量，没有扩缩容时等于 To
+	To       int  // 新的分片数量
+	Migrated int  // 已经迁移完成的旧分片数量
+}
+
+// ShardCount 返回当前（扩缩容期间为目标）分片数量
+func (m *SharedMap[K, V]) ShardCount() int {
+	return len(m.state.Load().cur.shards)
+}
+
+// ResizeProgress 返回扩缩容的进度
+func (m *SharedMap[K, V]) ResizeProgress() ResizeProgress {
+	st := m.state.Load()
+	p := ResizeProgress{From: len(st.cur.shards), To: len(st.cur.shards)}
+	if st.old != nil {
+		p.Resizing = true
+		p.From = len(st.old.shards)
+		p.Migrated = int(st.migrated.Load())
+	}
+	return p
+}
+
+// Resize 将分片数量调整为 shardCount，调用会阻塞到迁移完成。
+// 迁移是增量进行的：每次只锁一个旧分片以及它的数据要写入的新分片，
+// 期间其他协程的读写照常进行，访问到尚未迁移的 key 时会顺带迁移它所在的分片。
+// 同一时间只会进行一次扩缩容，并发调用会排队执行
+func (m *SharedMap[K, V]) Resize(shardCount int) error {
+	if shardCount <= 0 {
+		return ErrInvalidShardCount
+	}
+	m.resizeMu.Lock()
+	defer m.resizeMu.Unlock()
+
+	st := m.state.Load()
+	if len(st.cur.shards) == shardCount {
+		return nil
+	}
+	next := &tableState[K, V]{cur: newShardTable[K, V](shardCount), old: st.cur}
+	m.state.Store(next)
+
+	for _, s := range next.old.shards {
+		m.migrateShard(next, s)
+		// 每迁移一个分片让出一次处理器，避免长时间占用
+		runtime.Gosched()
+	}
+	m.state.Store(&tableState[K, V]{cur: next.cur})
+	return nil
+}
+
+// migrateShard 把旧表中的分片 s 迁移到新表，已经迁移过的分片直接返回。
+// 锁顺序固定为先旧分片后新分片，普通读写同一时间只持有一个分片锁，因此不会死锁
+func (m *SharedMap[K, V]) migrateShard(st *tableState[K, V], s *mapShard[K, V]) {
+	s.RLock()
+	migrated := s.migrated
+	s.RUnlock()
+	if migrated {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if s.migrated {
+		return
+	}
+	for k, v := range s.items {
+		target := st.cur.shard(m.hash(k))
+		target.Lock()
+		target.items[k] = v
+		target.Unlock()
+	}
+	s.items = nil
+	s.migrated = true
+	st.migrated.Add(1)
+}
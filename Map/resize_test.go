@@ -0,0 +1,104 @@
+package Map
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSharedMapResize(t *testing.T) {
+	m := NewSharedMap[int, int](4, nil)
+	for i := 0; i < 10000; i++ {
+		m.Put(i, i)
+	}
+	for _, n := range []int{64, 3, 1, 16} {
+		if err := m.Resize(n); err != nil {
+			t.Fatalf("Resize(%d): %v", n, err)
+		}
+		if m.ShardCount() != n {
+			t.Fatalf("ShardCount() = %d, want %d", m.ShardCount(), n)
+		}
+		if p := m.ResizeProgress(); p.Resizing {
+			t.Fatalf("resize still in progress after Resize returned: %+v", p)
+		}
+		if m.Len() != 10000 {
+			t.Fatalf("Len() = %d after resizing to %d", m.Len(), n)
+		}
+		for i := 0; i < 10000; i++ {
+			if v, ok := m.Get(i); !ok || v != i {
+				t.Fatalf("Get(%d) = (%v, %v) after resizing to %d", i, v, ok, n)
+			}
+		}
+	}
+	if err := m.Resize(0); err != ErrInvalidShardCount {
+		t.Fatalf("Resize(0) = %v, want ErrInvalidShardCount", err)
+	}
+}
+
+// TestSharedMapResizeConcurrent 扩缩容期间并发读写，不能丢失或读到错误的数据
+func TestSharedMapResizeConcurrent(t *testing.T) {
+	const writers, perWriter = 8, 5000
+	m := NewSharedMap[int, int](2, nil)
+
+	var wg sync.WaitGroup
+	var stop int32
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				key := w*perWriter + i
+				m.Put(key, key)
+				m.Compute(-1, func(old int, _ bool) (int, bool) { return old + 1, true })
+				if v, ok := m.Get(key); !ok || v != key {
+					t.Errorf("Get(%d) = (%v, %v) right after Put", key, v, ok)
+					return
+				}
+				if i%3 == 0 {
+					m.Delete(key)
+					m.Put(key, key)
+				}
+			}
+		}(w)
+	}
+
+	// 另一个协程不断观察扩缩容进度，迁移数量不能超过旧分片数量
+	var observed sync.WaitGroup
+	var sawProgress int32
+	observed.Add(1)
+	go func() {
+		defer observed.Done()
+		for atomic.LoadInt32(&stop) == 0 {
+			p := m.ResizeProgress()
+			if p.Resizing {
+				atomic.StoreInt32(&sawProgress, 1)
+				if p.Migrated > p.From {
+					t.Errorf("migrated %d shards of %d", p.Migrated, p.From)
+				}
+			}
+			m.Len()
+		}
+	}()
+
+	for _, n := range []int{64, 7, 128, 2, 32} {
+		if err := m.Resize(n); err != nil {
+			t.Fatalf("Resize(%d): %v", n, err)
+		}
+	}
+	wg.Wait()
+	atomic.StoreInt32(&stop, 1)
+	observed.Wait()
+
+	if m.Len() != writers*perWriter+1 {
+		t.Fatalf("Len() = %d, want %d", m.Len(), writers*perWriter+1)
+	}
+	for key := 0; key < writers*perWriter; key++ {
+		if v, ok := m.Get(key); !ok || v != key {
+			t.Fatalf("Get(%d) = (%v, %v)", key, v, ok)
+		}
+	}
+	if v, _ := m.Get(-1); v != writers*perWriter {
+		t.Fatalf("counter = %d, want %d: updates were lost during resize", v, writers*perWriter)
+	}
+	t.Logf("observed an in-progress resize: %v", atomic.LoadInt32(&sawProgress) == 1)
+}
@@ -0,0 +1,247 @@
+package Map
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// DefaultShardCount 默认的分片数量
+const DefaultShardCount = 32
+
+// HashFunc 将 key 映射为 64 位哈希值，用于选择 key 所在的分片
+type HashFunc[K comparable] func(key K) uint64
+
+// FNVHash 使用 FNV-1a 计算 key 的哈希值
+func FNVHash[K comparable](key K) uint64 {
+	h := fnv.New64a()
+	h.Write(keyBytes(key))
+	return h.Sum64()
+}
+
+// XXHash 使用 xxhash 计算 key 的哈希值，速度比 FNV 更快
+func XXHash[K comparable](key K) uint64 {
+	return xxhash.Sum64(keyBytes(key))
+}
+
+// keyBytes 将 key 转换为字节序列，常见类型走快速路径，其余类型使用 fmt 格式化
+func keyBytes(key any) []byte {
+	switch k := key.(type) {
+	case string:
+		return []byte(k)
+	case int:
+		return strconv.AppendInt(nil, int64(k), 10)
+	case int32:
+		return strconv.AppendInt(nil, int64(k), 10)
+	case int64:
+		return strconv.AppendInt(nil, k, 10)
+	case uint:
+		return strconv.AppendUint(nil, uint64(k), 10)
+	case uint32:
+		return strconv.AppendUint(nil, uint64(k), 10)
+	case uint64:
+		return strconv.AppendUint(nil, k, 10)
+	default:
+		return []byte(fmt.Sprintf("%T:%v", key, key))
+	}
+}
+
+// mapShard 一个分片，拥有独立的读写锁
+type mapShard[K comparable, V any] struct {
+	sync.RWMutex
+	items    map[K]V
+	migrated bool // 扩缩容时该分片的数据已经迁移到新的分片表，在分片锁内读写
+	// versions 按 key 的哈希值分成若干条带的版本号，每次加写锁时递增 key 所在条带的版本，
+	// 乐观事务据此判断读过的 key 是否被修改过，见 txn.go
+	versions [shardStripes]uint64
+}
+
+// shardStripes 每个分片的版本条带数量
+const shardStripes = 64
+
+// stripe 返回哈希值 h 所在的版本条带。分片由哈希值的低位选择，条带使用高位
+func stripe(h uint64) int {
+	return int(h >> 58)
+}
+
+// shardTable 一组分片
+type shardTable[K comparable, V any] struct {
+	shards []*mapShard[K, V]
+}
+
+func newShardTable[K comparable, V any](shardCount int) *shardTable[K, V] {
+	shards := make([]*mapShard[K, V], shardCount)
+	for i := range shards {
+		shards[i] = &mapShard[K, V]{items: make(map[K]V)}
+	}
+	return &shardTable[K, V]{shards: shards}
+}
+
+func (t *shardTable[K, V]) shard(hash uint64) *mapShard[K, V] {
+	return t.shards[t.index(hash)]
+}
+
+// index 返回哈希值 hash 所在分片的下标
+func (t *shardTable[K, V]) index(hash uint64) int {
+	return int(hash % uint64(len(t.shards)))
+}
+
+// SharedMap 以区块化的形式进行加锁：key 按哈希值分散到多个分片，
+// 每个分片使用独立的读写锁，不同分片上的操作互不阻塞，从而降低 MutexMap 全局锁的竞争。
+// 分片数量可以通过 Resize 在运行时调整，见 resize.go
+type SharedMap[K comparable, V any] struct {
+	state    atomic.Pointer[tableState[K, V]]
+	hash     HashFunc[K]
+	resizeMu sync.Mutex // 保证同一时间只有一次扩缩容
+	watchers watchHub[K, V]
+}
+
+// NewSharedMap 创建分片数量为 shardCount 的 SharedMap，hash 为 nil 时使用 FNVHash。
+// shardCount 小于等于 0 时使用 DefaultShardCount
+func NewSharedMap[K comparable, V any](shardCount int, hash HashFunc[K]) *SharedMap[K, V] {
+	if shardCount <= 0 {
+		shardCount = DefaultShardCount
+	}
+	if hash == nil {
+		hash = FNVHash[K]
+	}
+	m := &SharedMap[K, V]{hash: hash}
+	m.state.Store(&tableState[K, V]{cur: newShardTable[K, V](shardCount)})
+	return m
+}
+
+// lockShard 返回 key 所在并且已经加锁的分片，write 决定加写锁还是读锁。
+// 扩缩容期间会先把 key 在旧表中的分片迁移到新表，再锁住新表中的分片
+func (m *SharedMap[K, V]) lockShard(key K, write bool) *mapShard[K, V] {
+	h := m.hash(key)
+	for {
+		st := m.state.Load()
+		if st.old != nil {
+			m.migrateShard(st, st.old.shard(h))
+		}
+		s := st.cur.shard(h)
+		if write {
+			s.Lock()
+		} else {
+			s.RLock()
+		}
+		if !s.migrated {
+			if write {
+				s.versions[stripe(h)]++
+			}
+			return s
+		}
+		// 加锁前分片已经被新一轮扩缩容迁走，重新读取分片表
+		if write {
+			s.Unlock()
+		} else {
+			s.RUnlock()
+		}
+	}
+}
+
+// Put 写入键值对
+func (m *SharedMap[K, V]) Put(key K, value V) {
+	s := m.lockShard(key, true)
+	old, ok := s.items[key]
+	s.items[key] = value
+	m.watchers.put(key, old, ok, value)
+	s.Unlock()
+}
+
+// Get 返回 key 对应的值以及 key 是否存在
+func (m *SharedMap[K, V]) Get(key K) (V, bool) {
+	s := m.lockShard(key, false)
+	v, ok := s.items[key]
+	s.RUnlock()
+	return v, ok
+}
+
+// Remove 删除 key
+func (m *SharedMap[K, V]) Remove(key K) {
+	m.Delete(key)
+}
+
+// Delete 删除 key，返回删除前 key 是否存在
+func (m *SharedMap[K, V]) Delete(key K) bool {
+	s := m.lockShard(key, true)
+	defer s.Unlock()
+	old, ok := s.items[key]
+	if ok {
+		delete(s.items, key)
+		m.watchers.delete(key, old)
+	}
+	return ok
+}
+
+// LoadOrStore key 存在时返回已有的值和 true，否则写入 value 并返回 value 和 false
+func (m *SharedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	s := m.lockShard(key, true)
+	defer s.Unlock()
+	return loadOrStore(s.items, &m.watchers, key, value)
+}
+
+// Len 返回所有分片中的元素总数，并发写入时只是一个近似值
+func (m *SharedMap[K, V]) Len() int {
+	n := 0
+	m.visitShards(func(s *mapShard[K, V]) {
+		n += len(s.items)
+	}, func() { n = 0 })
+	return n
+}
+
+// Range 遍历所有键值对，f 返回 false 时停止遍历。
+// 先在各分片的读锁下复制一份快照再调用 f，因此 f 中可以安全地修改 SharedMap
+func (m *SharedMap[K, V]) Range(f func(key K, value V) bool) {
+	var items []mapItem[K, V]
+	m.visitShards(func(s *mapShard[K, V]) {
+		for k, v := range s.items {
+			items = append(items, mapItem[K, V]{key: k, value: v})
+		}
+	}, func() { items = items[:0] })
+
+	for _, item := range items {
+		if !f(item.key, item.value) {
+			return
+		}
+	}
+}
+
+// visitShards 在读锁下依次访问当前分片表的每个分片。
+// 扩缩容期间先协助把剩余的旧分片迁移完；访问过程中如果遇到被新一轮扩缩容迁走的分片，
+// 调用 reset 丢弃已经收集的结果并重新开始
+func (m *SharedMap[K, V]) visitShards(visit func(s *mapShard[K, V]), reset func()) {
+	for {
+		st := m.state.Load()
+		if st.old != nil {
+			for _, s := range st.old.shards {
+				m.migrateShard(st, s)
+			}
+		}
+		complete := true
+		for _, s := range st.cur.shards {
+			s.RLock()
+			if s.migrated {
+				s.RUnlock()
+				complete = false
+				break
+			}
+			visit(s)
+			s.RUnlock()
+		}
+		if complete {
+			return
+		}
+		reset()
+	}
+}
+
+// mapItem 遍历时使用的键值对快照
+type mapItem[K comparable, V any] struct {
+	key   K
+	value V
+}
@@ -0,0 +1,84 @@
+package Map
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSharedMap(t *testing.T) {
+	for name, hash := range map[string]HashFunc[int]{"fnv": FNVHash[int], "xxhash": XXHash[int]} {
+		t.Run(name, func(t *testing.T) {
+			m := NewSharedMap[int, int](8, hash)
+			for i := 0; i < 100; i++ {
+				m.Put(i, i*i)
+			}
+			if got, ok := m.Get(7); !ok || got != 49 {
+				t.Fatalf("Get(7) = (%v, %v), want 49", got, ok)
+			}
+			if _, ok := m.Get(1000); ok {
+				t.Fatalf("Get on a missing key reported ok")
+			}
+			if m.Len() != 100 {
+				t.Fatalf("Len() = %d, want 100", m.Len())
+			}
+
+			m.Remove(7)
+			if _, ok := m.Get(7); ok {
+				t.Fatalf("key 7 still present after Remove")
+			}
+			if !m.Delete(8) || m.Delete(8) {
+				t.Fatalf("Delete should report whether the key existed")
+			}
+
+			if v, loaded := m.LoadOrStore(9, -1); !loaded || v != 81 {
+				t.Fatalf("LoadOrStore existing = (%v, %v)", v, loaded)
+			}
+			if v, loaded := m.LoadOrStore(1000, 1); loaded || v != 1 {
+				t.Fatalf("LoadOrStore new = (%v, %v)", v, loaded)
+			}
+
+			seen := 0
+			m.Range(func(key, value int) bool {
+				seen++
+				// 遍历过程中修改 map 不应死锁
+				m.Put(key, value)
+				return true
+			})
+			if seen != m.Len() {
+				t.Fatalf("Range visited %d keys, want %d", seen, m.Len())
+			}
+		})
+	}
+}
+
+func TestSharedMapConcurrent(t *testing.T) {
+	m := NewSharedMap[int, int](0, nil)
+	var wg sync.WaitGroup
+	var winners sync.Map
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.Put(g*1000+i, i)
+				// 负数 key 专门用于竞争 LoadOrStore
+				if _, loaded := m.LoadOrStore(-i-1, g); !loaded {
+					winners.Store(-i-1, g)
+				}
+				m.Get(-i - 1)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if m.Len() != 17*1000 {
+		t.Fatalf("Len() = %d, want %d", m.Len(), 17*1000)
+	}
+	// 每个 key 只能有一个 LoadOrStore 成功写入
+	for i := -1000; i < 0; i++ {
+		w, _ := winners.Load(i)
+		if got, _ := m.Get(i); got != w {
+			t.Fatalf("key %d = %v, LoadOrStore winner %v", i, got, w)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package Map
+
+import (
+	"cmp"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	skipListMaxLevel = 32
+	skipListP        = 4 // 每个节点以 1/skipListP 的概率多一层
+)
+
+// SkipListMap 按 key 有序的并发 map，基于 lazy skip list（Herlihy 等）实现。
+//
+// 读操作和遍历不加锁；写操作只锁住待修改节点及其各层前驱，并在加锁后校验前驱没有变化，
+// 校验失败时重新查找。删除先把节点标记为已删除（线性化点），再从各层摘除。
+// 被摘除节点的 next 指针不再修改，因此停在它上面的迭代器仍然可以继续向后遍历
+type SkipListMap[K any, V any] struct {
+	head  *slNode[K, V] // 哨兵节点，不保存数据
+	cmp   func(a, b K) int
+	count atomic.Int64
+}
+
+type slNode[K any, V any] struct {
+	key         K
+	value       atomic.Pointer[V]
+	next        []atomic.Pointer[slNode[K, V]]
+	mu          sync.Mutex
+	marked      atomic.Bool // 已被逻辑删除
+	fullyLinked atomic.Bool // 已经链入所有层
+}
+
+// live 节点已完整插入且没有被删除
+func (n *slNode[K, V]) live() bool {
+	return n.fullyLinked.Load() && !n.marked.Load()
+}
+
+// NewSkipListMap 创建按 key 自然顺序排序的 SkipListMap
+func NewSkipListMap[K cmp.Ordered, V any]() *SkipListMap[K, V] {
+	return NewSkipListMapFunc[K, V](cmp.Compare[K])
+}
+
+// NewSkipListMapFunc 创建使用比较函数 cmp 排序的 SkipListMap，
+// cmp(a, b) 在 a < b、a == b、a > b 时分别返回负数、0、正数
+func NewSkipListMapFunc[K any, V any](cmp func(a, b K) int) *SkipListMap[K, V] {
+	head := &slNode[K, V]{next: make([]atomic.Pointer[slNode[K, V]], skipListMaxLevel)}
+	head.fullyLinked.Store(true)
+	return &SkipListMap[K, V]{head: head, cmp: cmp}
+}
+
+// Get 返回 key 对应的值以及 key 是否存在
+func (m *SkipListMap[K, V]) Get(key K) (V, bool) {
+	pred := m.head
+	for level := skipListMaxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && m.cmp(curr.key, key) < 0 {
+			pred, curr = curr, curr.next[level].Load()
+		}
+		if curr != nil && m.cmp(curr.key, key) == 0 {
+			if curr.live() {
+				return *curr.value.Load(), true
+			}
+			break
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Put 写入键值对，key 已存在时替换其值
+func (m *SkipListMap[K, V]) Put(key K, value V) {
+	var preds, succs [skipListMaxLevel]*slNode[K, V]
+	topLevel := randomLevel()
+	for {
+		if found := m.find(key, &preds, &succs); found != -1 {
+			node := succs[found]
+			if node.marked.Load() {
+				// 节点正在被删除，等它摘除后重新插入
+				runtime.Gosched()
+				continue
+			}
+			for !node.fullyLinked.Load() {
+				runtime.Gosched()
+			}
+			// 加锁后再检查一次，避免把值写到已经删除的节点上
+			node.mu.Lock()
+			if node.marked.Load() {
+				node.mu.Unlock()
+				continue
+			}
+			node.value.Store(&value)
+			node.mu.Unlock()
+			return
+		}
+
+		highest, valid := lockPreds(&preds, topLevel, func(level int, pred *slNode[K, V]) bool {
+			succ := succs[level]
+			return !pred.marked.Load() && (succ == nil || !succ.marked.Load()) && pred.next[level].Load() == succ
+		})
+		if !valid {
+			unlockPreds(&preds, highest)
+			continue
+		}
+		node := &slNode[K, V]{key: key, next: make([]atomic.Pointer[slNode[K, V]], topLevel)}
+		node.value.Store(&value)
+		for level := 0; level < topLevel; level++ {
+			node.next[level].Store(succs[level])
+		}
+		for level := 0; level < topLevel; level++ {
+			preds[level].next[level].Store(node)
+		}
+		node.fullyLinked.Store(true)
+		unlockPreds(&preds, highest)
+		m.count.Add(1)
+		return
+	}
+}
+
+// Delete 删除 key，返回删除前 key 是否存在
+func (m *SkipListMap[K, V]) Delete(key K) bool {
+	_, ok := m.LoadAndDelete(key)
+	return ok
+}
+
+// LoadAndDelete 删除 key 并返回删除前的值，loaded 表示 key 是否存在
+func (m *SkipListMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	var preds, succs [skipListMaxLevel]*slNode[K, V]
+	var victim *slNode[K, V]
+	for {
+		found := m.find(key, &preds, &succs)
+		if victim == nil {
+			if found == -1 {
+				return value, false
+			}
+			node := succs[found]
+			// 还没插入完成的节点视为不存在，此时删除排在插入之前
+			if !node.fullyLinked.Load() || len(node.next)-1 != found || node.marked.Load() {
+				return value, false
+			}
+			node.mu.Lock()
+			if node.marked.Load() {
+				node.mu.Unlock()
+				return value, false
+			}
+			node.marked.Store(true)
+			victim = node
+		}
+
+		topLevel := len(victim.next)
+		highest, valid := lockPreds(&preds, topLevel, func(level int, pred *slNode[K, V]) bool {
+			return !pred.marked.Load() && pred.next[level].Load() == victim
+		})
+		if !valid {
+			unlockPreds(&preds, highest)
+			continue
+		}
+		for level := topLevel - 1; level >= 0; level-- {
+			preds[level].next[level].Store(victim.next[level].Load())
+		}
+		v := *victim.value.Load()
+		victim.mu.Unlock()
+		unlockPreds(&preds, highest)
+		m.count.Add(-1)
+		return v, true
+	}
+}
+
+// Len 返回元素数量
+func (m *SkipListMap[K, V]) Len() int {
+	return int(m.count.Load())
+}
+
+// Floor 返回小于等于 key 的最大 key 及其值
+func (m *SkipListMap[K, V]) Floor(key K) (K, V, bool) {
+	return entryOf(m.floor(key, true))
+}
+
+// Ceiling 返回大于等于 key 的最小 key 及其值
+func (m *SkipListMap[K, V]) Ceiling(key K) (K, V, bool) {
+	return entryOf(m.ceiling(key, true))
+}
+
+// Min 返回最小的 key 及其值
+func (m *SkipListMap[K, V]) Min() (K, V, bool) {
+	return entryOf(m.nextLive(m.head.next[0].Load()))
+}
+
+// Max 返回最大的 key 及其值
+func (m *SkipListMap[K, V]) Max() (K, V, bool) {
+	return entryOf(m.last())
+}
+
+// Range 按升序遍历 [from, to) 区间内的键值对，f 返回 false 时停止遍历。
+// 遍历是弱一致的：key 严格递增，但可能看不到遍历开始后的修改
+func (m *SkipListMap[K, V]) Range(from, to K, f func(key K, value V) bool) {
+	for n := m.ceiling(from, true); n != nil && m.cmp(n.key, to) < 0; n = m.nextLive(n.next[0].Load()) {
+		if !f(n.key, *n.value.Load()) {
+			return
+		}
+	}
+}
+
+// find 查找 key 在每一层的前驱和后继，返回 key 所在节点被找到的最高层，没有找到时返回 -1
+func (m *SkipListMap[K, V]) find(key K, preds, succs *[skipListMaxLevel]*slNode[K, V]) int {
+	found := -1
+	pred := m.head
+	for level := skipListMaxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && m.cmp(curr.key, key) < 0 {
+			pred, curr = curr, curr.next[level].Load()
+		}
+		if found == -1 && curr != nil && m.cmp(curr.key, key) == 0 {
+			found = level
+		}
+		preds[level], succs[level] = pred, curr
+	}
+	return found
+}
+
+// lockPreds 从低层到高层依次锁住前 topLevel 层的前驱（同一个节点只锁一次）并用 valid 校验，
+// 返回已加锁的最高层以及校验是否通过
+func lockPreds[K any, V any](preds *[skipListMaxLevel]*slNode[K, V], topLevel int,
+	valid func(level int, pred *slNode[K, V]) bool) (int, bool) {
+	highest := -1
+	var prev *slNode[K, V]
+	for level := 0; level < topLevel; level++ {
+		pred := preds[level]
+		if pred != prev {
+			pred.mu.Lock()
+			highest, prev = level, pred
+		}
+		if !valid(level, pred) {
+			return highest, false
+		}
+	}
+	return highest, true
+}
+
+func unlockPreds[K any, V any](preds *[skipListMaxLevel]*slNode[K, V], highest int) {
+	var prev *slNode[K, V]
+	for level := 0; level <= highest; level++ {
+		if preds[level] != prev {
+			preds[level].mu.Unlock()
+			prev = preds[level]
+		}
+	}
+}
+
+// floor 返回 key 小于等于（inclusive 为 false 时为小于）key 的最后一个有效节点
+func (m *SkipListMap[K, V]) floor(key K, inclusive bool) *slNode[K, V] {
+	for {
+		pred := m.head
+		for level := skipListMaxLevel - 1; level >= 0; level-- {
+			curr := pred.next[level].Load()
+			for curr != nil && m.before(curr.key, key, inclusive) {
+				pred, curr = curr, curr.next[level].Load()
+			}
+		}
+		if pred == m.head {
+			return nil
+		}
+		if pred.live() {
+			return pred
+		}
+		// 找到的节点已被删除或还没插入完成，继续找它前面的节点
+		key, inclusive = pred.key, false
+	}
+}
+
+// ceiling 返回 key 大于等于（inclusive 为 false 时为大于）key 的第一个有效节点
+func (m *SkipListMap[K, V]) ceiling(key K, inclusive bool) *slNode[K, V] {
+	pred := m.head
+	var curr *slNode[K, V]
+	for level := skipListMaxLevel - 1; level >= 0; level-- {
+		curr = pred.next[level].Load()
+		for curr != nil && !m.atOrAfter(curr.key, key, inclusive) {
+			pred, curr = curr, curr.next[level].Load()
+		}
+	}
+	return m.nextLive(curr)
+}
+
+// last 返回最后一个有效节点
+func (m *SkipListMap[K, V]) last() *slNode[K, V] {
+	pred := m.head
+	for level := skipListMaxLevel - 1; level >= 0; level-- {
+		for curr := pred.next[level].Load(); curr != nil; curr = curr.next[level].Load() {
+			pred = curr
+		}
+	}
+	if pred == m.head {
+		return nil
+	}
+	if pred.live() {
+		return pred
+	}
+	return m.floor(pred.key, false)
+}
+
+// nextLive 从 n 开始沿最底层向后找到第一个有效节点
+func (m *SkipListMap[K, V]) nextLive(n *slNode[K, V]) *slNode[K, V] {
+	for n != nil && !n.live() {
+		n = n.next[0].Load()
+	}
+	return n
+}
+
+// before a 排在 b 之前（inclusive 时包括相等）
+func (m *SkipListMap[K, V]) before(a, b K, inclusive bool) bool {
+	c := m.cmp(a, b)
+	return c < 0 || (inclusive && c == 0)
+}
+
+// atOrAfter a 排在 b 之后（inclusive 时包括相等）
+func (m *SkipListMap[K, V]) atOrAfter(a, b K, inclusive bool) bool {
+	c := m.cmp(a, b)
+	return c > 0 || (inclusive && c == 0)
+}
+
+func entryOf[K any, V any](n *slNode[K, V]) (key K, value V, ok bool) {
+	if n == nil {
+		return key, value, false
+	}
+	return n.key, *n.value.Load(), true
+}
+
+// randomLevel 随机生成新节点的层数，层数为 k 的概率为 (1/skipListP)^(k-1)
+func randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && rand.Intn(skipListP) == 0 {
+		level++
+	}
+	return level
+}
+
+// SkipListIterator SkipListMap 的迭代器，在并发写入时仍然有效。
+// 迭代是弱一致的：返回的 key 严格单调，不会重复，但可能看不到迭代开始后的修改
+type SkipListIterator[K any, V any] struct {
+	m       *SkipListMap[K, V]
+	reverse bool
+	started bool
+	seeking bool          // 调用了 Seek，下一次 Next 从 key 处重新定位
+	node    *slNode[K, V] // 当前节点，正向迭代时可能已被删除，但仍可以沿 next 继续
+	key     K
+	value   V
+}
+
+// Iterator 返回按升序遍历的迭代器，需要先调用 Next
+func (m *SkipListMap[K, V]) Iterator() *SkipListIterator[K, V] {
+	return &SkipListIterator[K, V]{m: m}
+}
+
+// ReverseIterator 返回按降序遍历的迭代器，需要先调用 Next
+func (m *SkipListMap[K, V]) ReverseIterator() *SkipListIterator[K, V] {
+	return &SkipListIterator[K, V]{m: m, reverse: true}
+}
+
+// Seek 重新定位迭代器，之后的 Next 返回第一个大于等于（逆序时为小于等于）key 的元素
+func (it *SkipListIterator[K, V]) Seek(key K) {
+	it.key, it.seeking = key, true
+}
+
+// Next 移动到下一个元素，没有更多元素时返回 false
+func (it *SkipListIterator[K, V]) Next() bool {
+	m := it.m
+	var n *slNode[K, V]
+	switch {
+	case it.seeking && it.reverse:
+		n = m.floor(it.key, true)
+	case it.seeking:
+		n = m.ceiling(it.key, true)
+	case !it.started && it.reverse:
+		n = m.last()
+	case !it.started:
+		n = m.nextLive(m.head.next[0].Load())
+	case it.node == nil:
+		return false
+	case it.reverse:
+		n = m.floor(it.key, false)
+	default:
+		n = m.nextLive(it.node.next[0].Load())
+	}
+	it.started, it.seeking = true, false
+	it.node = n
+	if n == nil {
+		return false
+	}
+	it.key, it.value = n.key, *n.value.Load()
+	return true
+}
+
+// Key 返回当前元素的 key
+func (it *SkipListIterator[K, V]) Key() K {
+	return it.key
+}
+
+// Value 返回当前元素的值，即迭代器移动到该元素时读到的值
+func (it *SkipListIterator[K, V]) Value() V {
+	return it.value
+}
@@ -0,0 +1,246 @@
+package Map
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSkipListMap(t *testing.T) {
+	m := NewSkipListMap[int, string]()
+	for _, k := range rand.Perm(100) {
+		m.Put(k*2, "v")
+	}
+	m.Put(10, "ten")
+	if v, ok := m.Get(10); !ok || v != "ten" || m.Len() != 100 {
+		t.Fatalf("Get(10) = (%q, %v), Len() = %d", v, ok, m.Len())
+	}
+	if _, ok := m.Get(11); ok {
+		t.Fatalf("Get(11) found a missing key")
+	}
+	if !m.Delete(10) || m.Delete(10) || m.Len() != 99 {
+		t.Fatalf("Delete should report whether the key existed")
+	}
+
+	bounds := []struct {
+		key           int
+		floor, ceil   int
+		hasFl, hasCei bool
+	}{
+		{key: 11, floor: 8, ceil: 12, hasFl: true, hasCei: true}, // 10 已删除
+		{key: 12, floor: 12, ceil: 12, hasFl: true, hasCei: true},
+		{key: -1, ceil: 0, hasCei: true},
+		{key: 199, floor: 198, hasFl: true},
+	}
+	for _, b := range bounds {
+		if k, _, ok := m.Floor(b.key); ok != b.hasFl || (ok && k != b.floor) {
+			t.Fatalf("Floor(%d) = (%d, %v), want (%d, %v)", b.key, k, ok, b.floor, b.hasFl)
+		}
+		if k, _, ok := m.Ceiling(b.key); ok != b.hasCei || (ok && k != b.ceil) {
+			t.Fatalf("Ceiling(%d) = (%d, %v), want (%d, %v)", b.key, k, ok, b.ceil, b.hasCei)
+		}
+	}
+	if k, _, _ := m.Min(); k != 0 {
+		t.Fatalf("Min() = %d, want 0", k)
+	}
+	if k, _, _ := m.Max(); k != 198 {
+		t.Fatalf("Max() = %d, want 198", k)
+	}
+
+	var got []int
+	m.Range(5, 15, func(k int, _ string) bool {
+		got = append(got, k)
+		return true
+	})
+	if want := []int{6, 8, 12, 14}; !equalInts(got, want) {
+		t.Fatalf("Range(5, 15) = %v, want %v", got, want)
+	}
+}
+
+func TestSkipListIterator(t *testing.T) {
+	m := NewSkipListMap[int, int]()
+	for i := 1; i <= 5; i++ {
+		m.Put(i*10, i)
+	}
+	collect := func(it *SkipListIterator[int, int]) []int {
+		var keys []int
+		for it.Next() {
+			if it.Value() != it.Key()/10 {
+				t.Fatalf("Value() = %d for key %d", it.Value(), it.Key())
+			}
+			keys = append(keys, it.Key())
+		}
+		return keys
+	}
+	if got := collect(m.Iterator()); !equalInts(got, []int{10, 20, 30, 40, 50}) {
+		t.Fatalf("forward iteration = %v", got)
+	}
+	if got := collect(m.ReverseIterator()); !equalInts(got, []int{50, 40, 30, 20, 10}) {
+		t.Fatalf("reverse iteration = %v", got)
+	}
+
+	it := m.Iterator()
+	it.Seek(25)
+	if got := collect(it); !equalInts(got, []int{30, 40, 50}) {
+		t.Fatalf("forward iteration from 25 = %v", got)
+	}
+	it = m.ReverseIterator()
+	it.Seek(30)
+	if got := collect(it); !equalInts(got, []int{30, 20, 10}) {
+		t.Fatalf("reverse iteration from 30 = %v", got)
+	}
+
+	// 删除迭代器所在的元素后迭代器仍然可以沿被删除节点的 next 继续
+	it = m.Iterator()
+	it.Next()
+	it.Next()
+	m.Put(35, 3)
+	m.Delete(20)
+	m.Delete(30)
+	if !it.Next() || it.Key() != 35 {
+		t.Fatalf("iterator did not continue past deleted keys, at %d", it.Key())
+	}
+}
+
+func TestSkipListComparator(t *testing.T) {
+	// 忽略大小写并按降序排列
+	m := NewSkipListMapFunc[string, int](func(a, b string) int {
+		return strings.Compare(strings.ToLower(b), strings.ToLower(a))
+	})
+	m.Put("apple", 1)
+	m.Put("Cherry", 2)
+	m.Put("banana", 3)
+	m.Put("APPLE", 4) // 与 apple 相等，覆盖
+	if m.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", m.Len())
+	}
+	var keys []string
+	for it := m.Iterator(); it.Next(); {
+		keys = append(keys, it.Key())
+	}
+	if strings.Join(keys, ",") != "Cherry,banana,apple" {
+		t.Fatalf("iteration order = %v", keys)
+	}
+	if v, _ := m.Get("Apple"); v != 4 {
+		t.Fatalf("Get(Apple) = %d, want 4", v)
+	}
+}
+
+// TestSkipListConcurrentIteration 并发写入期间迭代：key 必须严格有序，且从未修改过的 key 一个不漏
+func TestSkipListConcurrentIteration(t *testing.T) {
+	const stable, workers = 500, 4
+	m := NewSkipListMap[int, int]()
+	for i := 0; i < stable; i++ {
+		m.Put(i*4, i) // 4 的倍数不会被修改
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(w)))
+			for !stop.Load() {
+				k := r.Intn(stable)*4 + 1 + r.Intn(3)
+				if r.Intn(2) == 0 {
+					m.Put(k, k)
+				} else {
+					m.Delete(k)
+				}
+			}
+		}(w)
+	}
+
+	for round := 0; round < 50; round++ {
+		reverse := round%2 == 1
+		it := m.Iterator()
+		if reverse {
+			it = m.ReverseIterator()
+		}
+		seen, prev, first := 0, 0, true
+		for it.Next() {
+			k := it.Key()
+			if !first && (reverse && k >= prev || !reverse && k <= prev) {
+				t.Fatalf("iteration out of order: %d after %d (reverse=%v)", k, prev, reverse)
+			}
+			if k%4 == 0 {
+				seen++
+			}
+			prev, first = k, false
+		}
+		if seen != stable {
+			t.Fatalf("iteration saw %d stable keys, want %d (reverse=%v)", seen, stable, reverse)
+		}
+	}
+	stop.Store(true)
+	wg.Wait()
+
+	n := 0
+	m.Range(-1, stable*4, func(int, int) bool { n++; return true })
+	if n != m.Len() {
+		t.Fatalf("Range visited %d keys, Len() = %d", n, m.Len())
+	}
+}
+
+// TestSkipListLinearizable 复用 lockFreeMap_test.go 中的检查器，逐个 key 检查并发历史的线性一致性
+func TestSkipListLinearizable(t *testing.T) {
+	const workers, keys, opsPerWorker = 4, 6, 60
+	for round := 0; round < 20; round++ {
+		m := NewSkipListMap[int, int]()
+		var clock int64
+		histories := make([]map[int][]histOp, workers)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(int64(round*workers + w)))
+				local := make(map[int][]histOp)
+				for i := 0; i < opsPerWorker; i++ {
+					key := r.Intn(keys)
+					o := histOp{call: atomic.AddInt64(&clock, 1)}
+					switch r.Intn(3) {
+					case 0:
+						o.kind, o.value = histPut, w*opsPerWorker+i+1
+						m.Put(key, o.value)
+					case 1:
+						o.kind = histGet
+						o.value, o.ok = m.Get(key)
+					default:
+						o.kind = histDelete
+						o.ok = m.Delete(key)
+					}
+					o.ret = atomic.AddInt64(&clock, 1)
+					local[key] = append(local[key], o)
+				}
+				histories[w] = local
+			}(w)
+		}
+		wg.Wait()
+
+		for key := 0; key < keys; key++ {
+			var ops []histOp
+			for _, h := range histories {
+				ops = append(ops, h[key]...)
+			}
+			if !linearizable(ops) {
+				t.Fatalf("round %d key %d: history is not linearizable: %v", round, key, ops)
+			}
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
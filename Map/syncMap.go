@@ -5,35 +5,55 @@ import (
 )
 
 // MutexMap 对于map添加读写锁，可以保证并发安全
-type MutexMap struct {
-	v map[interface{}]interface{}
+type MutexMap[K comparable, V any] struct {
+	v map[K]V
 	sync.RWMutex
+	watchers watchHub[K, V]
 }
 
 // NewMutexMap init
-func NewMutexMap() *MutexMap {
-	return &MutexMap{}
+func NewMutexMap[K comparable, V any]() *MutexMap[K, V] {
+	return &MutexMap[K, V]{v: make(map[K]V)}
 }
-func (m *MutexMap) Put(key, value interface{}) {
+func (m *MutexMap[K, V]) Put(key K, value V) {
 	m.Lock()
 	defer m.Unlock()
+	old, ok := m.v[key]
 	m.v[key] = value
-
+	m.watchers.put(key, old, ok, value)
 }
-func (m *MutexMap) Get(key interface{}) interface{} {
+
+// Get 返回 key 对应的值以及 key 是否存在
+func (m *MutexMap[K, V]) Get(key K) (V, bool) {
 	m.RLock()
-	v := m.v[key]
+	v, ok := m.v[key]
 	m.RUnlock()
-	return v
+	return v, ok
 }
 
-func (m *MutexMap) Remove(key interface{}) {
+func (m *MutexMap[K, V]) Remove(key K) {
 	m.Lock()
 	defer m.Unlock()
-	delete(m.v, key)
+	if old, ok := m.v[key]; ok {
+		delete(m.v, key)
+		m.watchers.delete(key, old)
+	}
 }
 
-//以区块化的形式进行加锁
+// Len 返回元素个数
+func (m *MutexMap[K, V]) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.v)
+}
 
-type SharedMap struct {
+// Range 在读锁下遍历所有键值对，f 返回 false 时停止遍历，f 中不能修改 MutexMap
+func (m *MutexMap[K, V]) Range(f func(key K, value V) bool) {
+	m.RLock()
+	defer m.RUnlock()
+	for k, v := range m.v {
+		if !f(k, v) {
+			return
+		}
+	}
 }
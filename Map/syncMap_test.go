@@ -0,0 +1,50 @@
+package Map
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMutexMap(t *testing.T) {
+	m := NewMutexMap[string, int]()
+	// NewMutexMap 需要初始化内部 map，第一次 Put 不能 panic
+	m.Put("a", 1)
+	m.Put("b", 2)
+	if v, ok := m.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = (%v, %v), want (1, true)", v, ok)
+	}
+	if v, ok := m.Get("missing"); ok || v != 0 {
+		t.Fatalf("Get(missing) = (%v, %v), want zero value", v, ok)
+	}
+	m.Remove("a")
+	if _, ok := m.Get("a"); ok {
+		t.Fatalf("key a still present after Remove")
+	}
+	sum := 0
+	m.Range(func(key string, value int) bool {
+		sum += value
+		return true
+	})
+	if m.Len() != 1 || sum != 2 {
+		t.Fatalf("Len() = %d, sum = %d", m.Len(), sum)
+	}
+}
+
+func TestMutexMapConcurrent(t *testing.T) {
+	m := NewMutexMap[int, int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.Put(g*1000+i, i)
+				m.Get(i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if m.Len() != 8000 {
+		t.Fatalf("Len() = %d, want 8000", m.Len())
+	}
+}
@@ -0,0 +1,258 @@
+package Map
+
+import (
+	"errors"
+	"runtime"
+	"sort"
+)
+
+// MaxTxnRetries 乐观事务因冲突重试的最大次数
+const MaxTxnRetries = 16
+
+// ErrTxnConflict 乐观事务重试 MaxTxnRetries 次后仍然冲突
+var ErrTxnConflict = errors.New("transaction conflict: retries exhausted")
+
+// Tx SharedMap 上的一个事务。读过的 key 记录在读集合中，写入先缓存在写集合中，
+// 提交时才写入 SharedMap；事务函数返回错误时写集合被直接丢弃，即回滚。
+// Tx 只能在事务函数内使用，不能并发使用
+type Tx[K comparable, V any] struct {
+	m          *SharedMap[K, V]
+	optimistic bool
+	reads      map[K]txRead[K, V]
+	writes     map[K]txWrite[V]
+	touched    map[K]struct{} // 本次执行访问过的 key，即读集合和写集合的并集
+
+	// 以下字段只在悲观模式下使用
+	table  *shardTable[K, V]        // 加锁时的分片表，nil 表示还没有加锁
+	locked map[*mapShard[K, V]]bool // 持有写锁的分片
+}
+
+// txRead 读集合中的一项，乐观模式下记录读取时 key 所在的分片和条带版本
+type txRead[K comparable, V any] struct {
+	value   V
+	ok      bool
+	shard   *mapShard[K, V]
+	version uint64
+}
+
+// txWrite 写集合中的一项
+type txWrite[V any] struct {
+	value   V
+	deleted bool
+}
+
+func newTx[K comparable, V any](m *SharedMap[K, V], optimistic bool) *Tx[K, V] {
+	return &Tx[K, V]{
+		m:          m,
+		optimistic: optimistic,
+		reads:      make(map[K]txRead[K, V]),
+		writes:     make(map[K]txWrite[V]),
+		touched:    make(map[K]struct{}),
+	}
+}
+
+// Get 返回 key 的值，能读到本事务之前的写入，同一个 key 多次读取结果相同
+func (tx *Tx[K, V]) Get(key K) (V, bool) {
+	tx.touched[key] = struct{}{}
+	if w, ok := tx.writes[key]; ok {
+		if w.deleted {
+			var zero V
+			return zero, false
+		}
+		return w.value, true
+	}
+	if r, ok := tx.reads[key]; ok {
+		return r.value, r.ok
+	}
+	var r txRead[K, V]
+	if tx.optimistic {
+		r = tx.readVersioned(key)
+	} else {
+		r = tx.readLocked(key)
+	}
+	tx.reads[key] = r
+	return r.value, r.ok
+}
+
+// Put 在事务中写入键值对
+func (tx *Tx[K, V]) Put(key K, value V) {
+	tx.touched[key] = struct{}{}
+	tx.writes[key] = txWrite[V]{value: value}
+}
+
+// Delete 在事务中删除 key
+func (tx *Tx[K, V]) Delete(key K) {
+	tx.touched[key] = struct{}{}
+	tx.writes[key] = txWrite[V]{deleted: true}
+}
+
+// readVersioned 乐观模式：在读锁下读取值以及 key 所在条带的版本
+func (tx *Tx[K, V]) readVersioned(key K) txRead[K, V] {
+	s := tx.m.lockShard(key, false)
+	defer s.RUnlock()
+	v, ok := s.items[key]
+	return txRead[K, V]{value: v, ok: ok, shard: s, version: s.versions[stripe(tx.m.hash(key))]}
+}
+
+// readLocked 悲观模式：key 所在分片已加锁时直接读取。
+// 没有加锁时本次执行的结果会被丢弃并重试，这里只需要返回一个合理的值：
+// 还没有加任何锁时正常读取，已经持有其他分片的锁时只尝试加读锁，避免打乱加锁顺序造成死锁
+func (tx *Tx[K, V]) readLocked(key K) txRead[K, V] {
+	if tx.table == nil {
+		v, ok := tx.m.Get(key)
+		return txRead[K, V]{value: v, ok: ok}
+	}
+	s := tx.table.shard(tx.m.hash(key))
+	if tx.locked[s] {
+		v, ok := s.items[key]
+		return txRead[K, V]{value: v, ok: ok}
+	}
+	var r txRead[K, V]
+	if s.TryRLock() {
+		r.value, r.ok = s.items[key]
+		s.RUnlock()
+	}
+	return r
+}
+
+// Txn 以悲观方式执行事务：fn 访问的 key 所在的分片按下标顺序全部加写锁后再执行 fn，
+// fn 返回 nil 时提交写集合，返回错误时回滚并把错误原样返回。
+//
+// 事先并不知道 fn 会访问哪些 key，因此 fn 先在不加锁的情况下执行一次以确定要锁的分片；
+// 加锁后的执行中如果访问了新的分片，会释放所有锁、扩大加锁范围后重新执行。
+// 因此 fn 可能被执行多次，除了通过 tx 读写之外不应有副作用
+func (m *SharedMap[K, V]) Txn(fn func(tx *Tx[K, V]) error) error {
+	keys := make(map[K]struct{})
+	for {
+		tx := newTx(m, false)
+		var shards []*mapShard[K, V]
+		if len(keys) > 0 {
+			tx.table, shards = m.lockShards(keys)
+			tx.locked = make(map[*mapShard[K, V]]bool, len(shards))
+			for _, s := range shards {
+				tx.locked[s] = true
+			}
+		}
+
+		err := fn(tx)
+		complete := tx.table != nil || len(tx.touched) == 0
+		for k := range tx.touched {
+			if tx.table == nil || !tx.locked[tx.table.shard(m.hash(k))] {
+				complete = false
+			}
+			keys[k] = struct{}{}
+		}
+		if !complete {
+			// 访问了没有加锁的分片，本次执行的结果作废
+			unlockShards(shards)
+			continue
+		}
+		if err == nil {
+			m.apply(tx.table, tx.writes)
+		}
+		unlockShards(shards)
+		return err
+	}
+}
+
+// TxnOptimistic 以乐观方式执行事务：fn 执行期间不加锁，读到的每个 key 记录所在条带的版本，
+// 提交时按下标顺序锁住读写集合涉及的分片，版本都没有变化才写入，否则重试，
+// 重试 MaxTxnRetries 次仍然冲突时返回 ErrTxnConflict。
+//
+// 版本按条带而不是按 key 维护，同一条带上其他 key 的修改也会被当作冲突，只会多重试，不会出错。
+// fn 返回错误时，如果读到的数据已经过期，说明错误可能是由不一致的读取造成的，同样重试；
+// 否则回滚并返回该错误。fn 可能被执行多次，除了通过 tx 读写之外不应有副作用
+func (m *SharedMap[K, V]) TxnOptimistic(fn func(tx *Tx[K, V]) error) error {
+	for attempt := 0; attempt < MaxTxnRetries; attempt++ {
+		tx := newTx(m, true)
+		err := fn(tx)
+
+		table, shards := m.lockShards(tx.touched)
+		valid := m.validate(table, tx.reads)
+		if valid && err == nil {
+			m.apply(table, tx.writes)
+		}
+		unlockShards(shards)
+		if valid {
+			return err
+		}
+		runtime.Gosched()
+	}
+	return ErrTxnConflict
+}
+
+// validate 检查读集合中每个 key 所在的分片没有变化并且条带版本没有变化，调用方需要持有这些分片的锁
+func (m *SharedMap[K, V]) validate(table *shardTable[K, V], reads map[K]txRead[K, V]) bool {
+	for k, r := range reads {
+		h := m.hash(k)
+		s := table.shard(h)
+		if s != r.shard || s.versions[stripe(h)] != r.version {
+			return false
+		}
+	}
+	return true
+}
+
+// apply 把写集合写入 table，调用方需要持有写集合涉及的所有分片的写锁
+func (m *SharedMap[K, V]) apply(table *shardTable[K, V], writes map[K]txWrite[V]) {
+	for k, w := range writes {
+		h := m.hash(k)
+		s := table.shard(h)
+		s.versions[stripe(h)]++
+		old, had := s.items[k]
+		if w.deleted {
+			if had {
+				delete(s.items, k)
+				m.watchers.delete(k, old)
+			}
+			continue
+		}
+		s.items[k] = w.value
+		m.watchers.put(k, old, had, w.value)
+	}
+}
+
+// lockShards 按分片下标从小到大锁住 keys 所在的全部分片，返回加锁时的分片表以及加锁的分片。
+// 所有事务都按同样的顺序加锁，普通读写同一时间只持有一个分片锁，因此不会死锁。
+// 扩缩容期间先迁移 keys 所在的旧分片；加锁后发现分片已被新一轮扩缩容迁走时释放并重试
+func (m *SharedMap[K, V]) lockShards(keys map[K]struct{}) (*shardTable[K, V], []*mapShard[K, V]) {
+	for {
+		st := m.state.Load()
+		seen := make(map[int]bool)
+		var indexes []int
+		for k := range keys {
+			h := m.hash(k)
+			if st.old != nil {
+				m.migrateShard(st, st.old.shard(h))
+			}
+			i := int(h % uint64(len(st.cur.shards)))
+			if !seen[i] {
+				seen[i] = true
+				indexes = append(indexes, i)
+			}
+		}
+		sort.Ints(indexes)
+
+		shards := make([]*mapShard[K, V], 0, len(indexes))
+		migrated := false
+		for _, i := range indexes {
+			s := st.cur.shards[i]
+			s.Lock()
+			shards = append(shards, s)
+			if s.migrated {
+				migrated = true
+				break
+			}
+		}
+		if !migrated {
+			return st.cur, shards
+		}
+		unlockShards(shards)
+	}
+}
+
+func unlockShards[K comparable, V any](shards []*mapShard[K, V]) {
+	for i := len(shards) - 1; i >= 0; i-- {
+		shards[i].Unlock()
+	}
+}
@@ -0,0 +1,197 @@
+package Map
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+var errInsufficient = errors.New("insufficient balance")
+
+// transfer 在事务中从 from 转账 amount 到 to，余额不足时返回错误
+func transfer(tx *Tx[string, int], from, to string, amount int) error {
+	a, _ := tx.Get(from)
+	if a < amount {
+		return errInsufficient
+	}
+	b, _ := tx.Get(to)
+	tx.Put(from, a-amount)
+	tx.Put(to, b+amount)
+	return nil
+}
+
+type txnMode struct {
+	name string
+	run  func(m *SharedMap[string, int], fn func(tx *Tx[string, int]) error) error
+}
+
+var txnModes = []txnMode{
+	{"pessimistic", (*SharedMap[string, int]).Txn},
+	{"optimistic", (*SharedMap[string, int]).TxnOptimistic},
+}
+
+func TestTxn(t *testing.T) {
+	for _, mode := range txnModes {
+		t.Run(mode.name, func(t *testing.T) {
+			m := NewSharedMap[string, int](8, nil)
+			m.Put("alice", 100)
+			m.Put("bob", 50)
+
+			if err := mode.run(m, func(tx *Tx[string, int]) error { return transfer(tx, "alice", "bob", 30) }); err != nil {
+				t.Fatalf("transfer: %v", err)
+			}
+			if a, _ := m.Get("alice"); a != 70 {
+				t.Fatalf("alice = %d, want 70", a)
+			}
+
+			// 返回错误时回滚，之前的写入都不生效
+			err := mode.run(m, func(tx *Tx[string, int]) error {
+				tx.Put("carol", 1)
+				tx.Delete("bob")
+				return transfer(tx, "alice", "bob", 1000)
+			})
+			if err != errInsufficient {
+				t.Fatalf("Txn returned %v, want errInsufficient", err)
+			}
+			if _, ok := m.Get("carol"); ok || m.Len() != 2 {
+				t.Fatalf("rolled back transaction left writes behind")
+			}
+
+			// 事务内能读到自己的写入和删除
+			mode.run(m, func(tx *Tx[string, int]) error {
+				tx.Put("dave", 1)
+				if v, ok := tx.Get("dave"); !ok || v != 1 {
+					t.Fatalf("Get after Put in transaction = (%v, %v)", v, ok)
+				}
+				tx.Delete("bob")
+				if _, ok := tx.Get("bob"); ok {
+					t.Fatalf("Get after Delete in transaction found the key")
+				}
+				return nil
+			})
+			if _, ok := m.Get("bob"); ok {
+				t.Fatalf("bob still present after committed delete")
+			}
+			if v, _ := m.Get("dave"); v != 1 {
+				t.Fatalf("dave = %d, want 1", v)
+			}
+		})
+	}
+}
+
+// TestTxnConcurrentTransfers 并发转账并同时扩缩容，总余额保持不变，只读事务看到的总额也始终正确
+func TestTxnConcurrentTransfers(t *testing.T) {
+	const accounts, initial, workers, transfers = 16, 100, 8, 300
+	for _, mode := range txnModes {
+		t.Run(mode.name, func(t *testing.T) {
+			m := NewSharedMap[string, int](4, nil)
+			names := make([]string, accounts)
+			for i := range names {
+				names[i] = fmt.Sprint("acct", i)
+				m.Put(names[i], initial)
+			}
+
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					r := rand.New(rand.NewSource(int64(w)))
+					for i := 0; i < transfers; i++ {
+						from, to := names[r.Intn(accounts)], names[r.Intn(accounts)]
+						if from == to {
+							continue
+						}
+						err := mode.run(m, func(tx *Tx[string, int]) error {
+							return transfer(tx, from, to, 1+r.Intn(20))
+						})
+						if err != nil && err != errInsufficient && err != ErrTxnConflict {
+							t.Errorf("transfer: %v", err)
+						}
+					}
+				}(w)
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					total := 0
+					err := mode.run(m, func(tx *Tx[string, int]) error {
+						total = 0
+						for _, name := range names {
+							v, _ := tx.Get(name)
+							total += v
+						}
+						return nil
+					})
+					if err == nil && total != accounts*initial {
+						t.Errorf("read-only transaction saw total %d, want %d", total, accounts*initial)
+					}
+				}
+			}()
+			for _, n := range []int{16, 3, 32} {
+				m.Resize(n)
+			}
+			wg.Wait()
+
+			total := 0
+			for _, name := range names {
+				v, _ := m.Get(name)
+				if v < 0 {
+					t.Fatalf("%s has negative balance %d", name, v)
+				}
+				total += v
+			}
+			if total != accounts*initial {
+				t.Fatalf("total balance = %d, want %d", total, accounts*initial)
+			}
+		})
+	}
+}
+
+func TestTxnOptimisticConflict(t *testing.T) {
+	m := NewSharedMap[string, int](0, nil)
+	m.Put("k", 0)
+	calls := 0
+	err := m.TxnOptimistic(func(tx *Tx[string, int]) error {
+		calls++
+		v, _ := tx.Get("k")
+		m.Put("k", v+1) // 事务外的修改，每次提交都会冲突
+		tx.Put("k", -1)
+		return nil
+	})
+	if err != ErrTxnConflict || calls != MaxTxnRetries {
+		t.Fatalf("TxnOptimistic = %v after %d calls, want ErrTxnConflict after %d", err, calls, MaxTxnRetries)
+	}
+	if v, _ := m.Get("k"); v != MaxTxnRetries {
+		t.Fatalf("k = %d: a conflicting transaction was committed", v)
+	}
+}
+
+func TestTxnWatch(t *testing.T) {
+	m := NewSharedMap[string, int](0, nil)
+	m.Put("a", 1)
+	w := m.WatchPrefix(context.Background(), "", 4)
+	defer w.Cancel()
+	m.Txn(func(tx *Tx[string, int]) error {
+		tx.Delete("a")
+		tx.Put("b", 2)
+		return nil
+	})
+	m.Txn(func(tx *Tx[string, int]) error {
+		tx.Put("c", 3)
+		return errInsufficient
+	})
+
+	got := make(map[string]WatchEventType)
+	for i := 0; i < 2; i++ {
+		e := <-w.C()
+		got[e.Key] = e.Type
+	}
+	if got["a"] != WatchDelete || got["b"] != WatchPut || len(w.C()) != 0 {
+		t.Fatalf("unexpected events %v, %d pending", got, len(w.C()))
+	}
+}
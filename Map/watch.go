@@ -0,0 +1,245 @@
+package Map
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultWatchBuffer 订阅者默认的事件缓冲区大小
+const DefaultWatchBuffer = 64
+
+// WatchEventType 变更事件的类型
+type WatchEventType int
+
+const (
+	WatchPut      WatchEventType = iota // 写入或修改
+	WatchDelete                         // 删除
+	WatchOverflow                       // 订阅者处理太慢，缓冲区满后有事件被丢弃，需要重新读取全量数据
+)
+
+func (t WatchEventType) String() string {
+	switch t {
+	case WatchPut:
+		return "put"
+	case WatchDelete:
+		return "delete"
+	case WatchOverflow:
+		return "overflow"
+	default:
+		return "unknown"
+	}
+}
+
+// WatchEvent 一次变更事件
+type WatchEvent[K comparable, V any] struct {
+	Type     WatchEventType
+	Key      K
+	OldValue V      // 变更前的值，HasOld 为 false 时为零值
+	HasOld   bool   // 变更前 key 是否存在
+	NewValue V      // Put 事件写入的新值
+	Dropped  uint64 // Overflow 事件：上一次成功投递之后丢弃的事件数量
+}
+
+// Watcher 一个订阅，事件通过 C 返回的通道投递，取消订阅后通道会被关闭
+type Watcher[K comparable, V any] struct {
+	hub      *watchHub[K, V]
+	key      K
+	prefix   string
+	isPrefix bool
+
+	ch      chan WatchEvent[K, V]
+	mu      sync.Mutex // 保护 pending、closed 和 stopCtx，保证同一个订阅者的事件按顺序投递
+	pending uint64     // 已丢弃但还没有通过 Overflow 事件通知的数量
+	closed  bool
+	dropped atomic.Uint64 // 累计丢弃的事件数量
+
+	cancelOnce sync.Once
+	stopCtx    func() bool // 注销 ctx 结束时的取消回调
+}
+
+// C 返回事件通道
+func (w *Watcher[K, V]) C() <-chan WatchEvent[K, V] {
+	return w.ch
+}
+
+// Dropped 返回累计因缓冲区满而丢弃的事件数量
+func (w *Watcher[K, V]) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Cancel 取消订阅并关闭事件通道，可以重复调用
+func (w *Watcher[K, V]) Cancel() {
+	w.cancelOnce.Do(func() {
+		w.mu.Lock()
+		stop := w.stopCtx
+		w.mu.Unlock()
+		if stop != nil {
+			stop()
+		}
+		w.hub.remove(w)
+		w.mu.Lock()
+		w.closed = true
+		close(w.ch)
+		w.mu.Unlock()
+	})
+}
+
+// send 非阻塞地投递事件。缓冲区满时丢弃事件并记数，
+// 等缓冲区有空位时先投递一个 Overflow 事件告诉订阅者丢了多少，再继续投递后续事件
+func (w *Watcher[K, V]) send(e WatchEvent[K, V]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	if w.pending > 0 {
+		select {
+		case w.ch <- WatchEvent[K, V]{Type: WatchOverflow, Dropped: w.pending}:
+			w.pending = 0
+		default:
+			w.pending++
+			w.dropped.Add(1)
+			return
+		}
+	}
+	select {
+	case w.ch <- e:
+	default:
+		w.pending++
+		w.dropped.Add(1)
+	}
+}
+
+func (w *Watcher[K, V]) matches(key K) bool {
+	if w.isPrefix {
+		return strings.HasPrefix(keyString(key), w.prefix)
+	}
+	return w.key == key
+}
+
+// keyString 前缀订阅使用的 key 的字符串形式
+func keyString(key any) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+// watchHub 管理一个 map 上的所有订阅。零值可用，没有订阅者时通知只有一次原子读的开销。
+// 通知在 map 的锁内调用，保证同一个 key 的事件顺序与变更顺序一致；投递是非阻塞的，不会拖慢写入
+type watchHub[K comparable, V any] struct {
+	mu       sync.RWMutex
+	keys     map[K]map[*Watcher[K, V]]struct{}
+	prefixes map[*Watcher[K, V]]struct{}
+	n        atomic.Int32
+}
+
+// watch 注册订阅，ctx 结束时自动取消
+func (h *watchHub[K, V]) watch(ctx context.Context, w *Watcher[K, V], buffer int) *Watcher[K, V] {
+	if buffer <= 0 {
+		buffer = DefaultWatchBuffer
+	}
+	w.hub = h
+	w.ch = make(chan WatchEvent[K, V], buffer)
+
+	h.mu.Lock()
+	if w.isPrefix {
+		if h.prefixes == nil {
+			h.prefixes = make(map[*Watcher[K, V]]struct{})
+		}
+		h.prefixes[w] = struct{}{}
+	} else {
+		if h.keys == nil {
+			h.keys = make(map[K]map[*Watcher[K, V]]struct{})
+		}
+		if h.keys[w.key] == nil {
+			h.keys[w.key] = make(map[*Watcher[K, V]]struct{})
+		}
+		h.keys[w.key][w] = struct{}{}
+	}
+	h.n.Add(1)
+	h.mu.Unlock()
+
+	if ctx.Err() != nil {
+		w.Cancel()
+		return w
+	}
+	stop := context.AfterFunc(ctx, w.Cancel)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		// 回调注册之前订阅已经被取消，Cancel 没有拿到 stop
+		stop()
+		return w
+	}
+	w.stopCtx = stop
+	return w
+}
+
+func (h *watchHub[K, V]) remove(w *Watcher[K, V]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if w.isPrefix {
+		delete(h.prefixes, w)
+	} else {
+		delete(h.keys[w.key], w)
+		if len(h.keys[w.key]) == 0 {
+			delete(h.keys, w.key)
+		}
+	}
+	h.n.Add(-1)
+}
+
+// put 通知 key 被写入 value，old 和 hasOld 是写入前的状态
+func (h *watchHub[K, V]) put(key K, old V, hasOld bool, value V) {
+	if h.n.Load() == 0 {
+		return
+	}
+	h.notify(WatchEvent[K, V]{Type: WatchPut, Key: key, OldValue: old, HasOld: hasOld, NewValue: value})
+}
+
+// delete 通知 key 被删除，old 是删除前的值
+func (h *watchHub[K, V]) delete(key K, old V) {
+	if h.n.Load() == 0 {
+		return
+	}
+	h.notify(WatchEvent[K, V]{Type: WatchDelete, Key: key, OldValue: old, HasOld: true})
+}
+
+func (h *watchHub[K, V]) notify(e WatchEvent[K, V]) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for w := range h.keys[e.Key] {
+		w.send(e)
+	}
+	for w := range h.prefixes {
+		if w.matches(e.Key) {
+			w.send(e)
+		}
+	}
+}
+
+// Watch 订阅 key 的变更，buffer 为事件缓冲区大小，小于等于 0 时使用 DefaultWatchBuffer。
+// ctx 结束或调用 Cancel 后订阅取消，事件通道被关闭
+func (m *MutexMap[K, V]) Watch(ctx context.Context, key K, buffer int) *Watcher[K, V] {
+	return m.watchers.watch(ctx, &Watcher[K, V]{key: key}, buffer)
+}
+
+// WatchPrefix 订阅字符串形式以 prefix 开头的所有 key 的变更，非 string 类型的 key 使用 fmt.Sprint 转换
+func (m *MutexMap[K, V]) WatchPrefix(ctx context.Context, prefix string, buffer int) *Watcher[K, V] {
+	return m.watchers.watch(ctx, &Watcher[K, V]{prefix: prefix, isPrefix: true}, buffer)
+}
+
+// Watch 订阅 key 的变更，buffer 为事件缓冲区大小，小于等于 0 时使用 DefaultWatchBuffer。
+// ctx 结束或调用 Cancel 后订阅取消，事件通道被关闭
+func (m *SharedMap[K, V]) Watch(ctx context.Context, key K, buffer int) *Watcher[K, V] {
+	return m.watchers.watch(ctx, &Watcher[K, V]{key: key}, buffer)
+}
+
+// WatchPrefix 订阅字符串形式以 prefix 开头的所有 key 的变更，非 string 类型的 key 使用 fmt.Sprint 转换
+func (m *SharedMap[K, V]) WatchPrefix(ctx context.Context, prefix string, buffer int) *Watcher[K, V] {
+	return m.watchers.watch(ctx, &Watcher[K, V]{prefix: prefix, isPrefix: true}, buffer)
+}
@@ -0,0 +1,242 @@
+package Map
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// watchableMap MutexMap 和 SharedMap 共有的订阅接口，用于对两种实现运行同一组测试
+type watchableMap interface {
+	Put(key string, value int)
+	Get(key string) (int, bool)
+	Remove(key string)
+	Compute(key string, fn func(old int, loaded bool) (int, bool)) (int, bool)
+	CompareAndSwap(key string, old, new int) bool
+	Watch(ctx context.Context, key string, buffer int) *Watcher[string, int]
+	WatchPrefix(ctx context.Context, prefix string, buffer int) *Watcher[string, int]
+}
+
+func watchableMaps() map[string]func() watchableMap {
+	return map[string]func() watchableMap{
+		"MutexMap":  func() watchableMap { return NewMutexMap[string, int]() },
+		"SharedMap": func() watchableMap { return NewSharedMap[string, int](4, nil) },
+	}
+}
+
+// recv 在超时前从订阅中读取一个事件
+func recv(t *testing.T, w *Watcher[string, int]) WatchEvent[string, int] {
+	t.Helper()
+	select {
+	case e, ok := <-w.C():
+		if !ok {
+			t.Fatalf("watch channel closed unexpectedly")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for a watch event")
+	}
+	return WatchEvent[string, int]{}
+}
+
+func expectNoEvent(t *testing.T, w *Watcher[string, int]) {
+	t.Helper()
+	select {
+	case e := <-w.C():
+		t.Fatalf("unexpected event %+v", e)
+	default:
+	}
+}
+
+func TestWatch(t *testing.T) {
+	for name, newMap := range watchableMaps() {
+		t.Run(name, func(t *testing.T) {
+			m := newMap()
+			w := m.Watch(context.Background(), "a", 0)
+			defer w.Cancel()
+
+			m.Put("a", 1)
+			m.Put("b", 1) // 不是订阅的 key
+			m.Put("a", 2)
+			m.Compute("a", func(old int, _ bool) (int, bool) { return old * 10, true })
+			m.CompareAndSwap("a", 1, 100) // 比较失败，没有修改
+			m.Remove("a")
+			m.Remove("a") // key 不存在，没有修改
+
+			want := []WatchEvent[string, int]{
+				{Type: WatchPut, Key: "a", NewValue: 1},
+				{Type: WatchPut, Key: "a", OldValue: 1, HasOld: true, NewValue: 2},
+				{Type: WatchPut, Key: "a", OldValue: 2, HasOld: true, NewValue: 20},
+				{Type: WatchDelete, Key: "a", OldValue: 20, HasOld: true},
+			}
+			for _, e := range want {
+				if got := recv(t, w); got != e {
+					t.Fatalf("got event %+v, want %+v", got, e)
+				}
+			}
+			expectNoEvent(t, w)
+		})
+	}
+}
+
+func TestWatchPrefix(t *testing.T) {
+	for name, newMap := range watchableMaps() {
+		t.Run(name, func(t *testing.T) {
+			m := newMap()
+			w := m.WatchPrefix(context.Background(), "user/", 0)
+			defer w.Cancel()
+
+			m.Put("user/1", 1)
+			m.Put("order/1", 1)
+			m.Put("user/2", 2)
+			m.Remove("user/1")
+
+			for _, key := range []string{"user/1", "user/2", "user/1"} {
+				if e := recv(t, w); e.Key != key {
+					t.Fatalf("got event for %q, want %q", e.Key, key)
+				}
+			}
+			expectNoEvent(t, w)
+		})
+	}
+
+	// 非 string 的 key 按 fmt.Sprint 的结果匹配
+	m := NewSharedMap[int, int](0, nil)
+	w := m.WatchPrefix(context.Background(), "1", 4)
+	defer w.Cancel()
+	m.Put(15, 1)
+	m.Put(25, 1)
+	if e := <-w.C(); e.Key != 15 {
+		t.Fatalf("got event for %d, want 15", e.Key)
+	}
+}
+
+// TestWatchOverflow 缓冲区满后事件被丢弃，订阅者读出空位后先收到 Overflow 事件
+func TestWatchOverflow(t *testing.T) {
+	m := NewMutexMap[string, int]()
+	w := m.Watch(context.Background(), "k", 2)
+	defer w.Cancel()
+
+	for i := 1; i <= 5; i++ {
+		m.Put("k", i)
+	}
+	if w.Dropped() != 3 {
+		t.Fatalf("Dropped() = %d, want 3", w.Dropped())
+	}
+	if e := recv(t, w); e.NewValue != 1 {
+		t.Fatalf("first event = %+v, want NewValue 1", e)
+	}
+	if e := recv(t, w); e.NewValue != 2 {
+		t.Fatalf("second event = %+v, want NewValue 2", e)
+	}
+
+	m.Put("k", 6)
+	if e := recv(t, w); e.Type != WatchOverflow || e.Dropped != 3 {
+		t.Fatalf("got %+v, want an overflow event with 3 dropped", e)
+	}
+	if e := recv(t, w); e.NewValue != 6 || e.OldValue != 5 {
+		t.Fatalf("got %+v, want the put of 6 over 5", e)
+	}
+	expectNoEvent(t, w)
+}
+
+func TestWatchCancel(t *testing.T) {
+	m := NewSharedMap[string, int](0, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	w := m.Watch(ctx, "k", 0)
+	m.Put("k", 1)
+	cancel()
+
+	// 已投递的事件仍可读出，之后通道被关闭
+	if e := recv(t, w); e.NewValue != 1 {
+		t.Fatalf("got %+v, want NewValue 1", e)
+	}
+	select {
+	case _, ok := <-w.C():
+		if ok {
+			t.Fatalf("received an event after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("channel not closed after the context was cancelled")
+	}
+	m.Put("k", 2)
+	w.Cancel() // 重复取消不会 panic
+	if m.watchers.n.Load() != 0 {
+		t.Fatalf("watcher still registered after cancel")
+	}
+}
+
+// TestWatchCancelledContext ctx 已经结束时订阅立即被取消，用 -race 运行
+func TestWatchCancelledContext(t *testing.T) {
+	m := NewSharedMap[string, int](0, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 100; i++ {
+		w := m.WatchPrefix(ctx, "", 0)
+		if _, ok := <-w.C(); ok {
+			t.Fatalf("received an event on a cancelled watch")
+		}
+	}
+	// ctx 在订阅期间结束，取消回调和 Watch 并发执行
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		go cancel()
+		w := m.Watch(ctx, "k", 0)
+		select {
+		case <-w.C():
+		case <-time.After(time.Second):
+			t.Fatalf("watch not closed after the context was cancelled")
+		}
+	}
+	if n := m.watchers.n.Load(); n != 0 {
+		t.Fatalf("%d watchers still registered", n)
+	}
+}
+
+// TestWatchConcurrent 并发写同一个 key，事件的 OldValue 串起来必须构成一条完整的修改链
+func TestWatchConcurrent(t *testing.T) {
+	const writers, perWriter = 8, 500
+	m := NewSharedMap[string, int](0, nil)
+	w := m.Watch(context.Background(), "k", writers*perWriter)
+
+	// 同时不断创建和取消其他订阅，检查注册和通知之间没有数据竞争
+	ctx, stop := context.WithCancel(context.Background())
+	var churn sync.WaitGroup
+	churn.Add(1)
+	go func() {
+		defer churn.Done()
+		for i := 0; ctx.Err() == nil; i++ {
+			o := m.WatchPrefix(ctx, fmt.Sprint(i%3), 1)
+			o.Cancel()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWriter; j++ {
+				m.Compute("k", func(old int, _ bool) (int, bool) { return old + 1, true })
+			}
+		}()
+	}
+	wg.Wait()
+	stop()
+	churn.Wait()
+	w.Cancel()
+
+	prev, n := 0, 0
+	for e := range w.C() {
+		if e.OldValue != prev || e.NewValue != prev+1 {
+			t.Fatalf("event %d out of order: %+v after value %d", n, e, prev)
+		}
+		prev = e.NewValue
+		n++
+	}
+	if n != writers*perWriter {
+		t.Fatalf("received %d events, want %d", n, writers*perWriter)
+	}
+}
@@ -0,0 +1,106 @@
+// Package lfu 提供 Map 和 LRU/internal/cache 共用的 O(1) LFU 访问记录
+package lfu
+
+import "container/list"
+
+// LFU O(1) 的 LFU：访问次数相同的 key 放在同一个桶中，桶按访问次数从小到大排成链表，
+// 每个桶内是一个 LRU 链表。访问时把 key 移到下一个桶，淘汰时取第一个桶中最久没有访问的 key。
+// 只记录访问顺序，不保存值，也不加锁，由调用方保护
+type LFU[K comparable] struct {
+	buckets *list.List          // 元素的值为 *bucket，按 freq 递增
+	items   map[K]*list.Element // key -> 桶内链表的节点，节点的值为 *item
+}
+
+type bucket struct {
+	freq  int
+	items *list.List // 队首是最近访问的
+}
+
+type item[K comparable] struct {
+	key    K
+	bucket *list.Element // 所在的桶
+}
+
+// New 创建空的 LFU
+func New[K comparable]() *LFU[K] {
+	return &LFU[K]{buckets: list.New(), items: make(map[K]*list.Element)}
+}
+
+// Add 以访问次数 1 记录 key，key 已存在时等同于 Access
+func (p *LFU[K]) Add(key K) {
+	if _, ok := p.items[key]; ok {
+		p.Access(key)
+		return
+	}
+	front := p.buckets.Front()
+	if front == nil || front.Value.(*bucket).freq != 1 {
+		front = p.buckets.PushFront(&bucket{freq: 1, items: list.New()})
+	}
+	p.items[key] = front.Value.(*bucket).items.PushFront(&item[K]{key: key, bucket: front})
+}
+
+// Access 把 key 的访问次数加一，key 不存在时什么也不做
+func (p *LFU[K]) Access(key K) {
+	e, ok := p.items[key]
+	if !ok {
+		return
+	}
+	it := e.Value.(*item[K])
+	cur := it.bucket
+	freq := cur.Value.(*bucket).freq
+	next := cur.Next()
+	if next == nil || next.Value.(*bucket).freq != freq+1 {
+		next = p.buckets.InsertAfter(&bucket{freq: freq + 1, items: list.New()}, cur)
+	}
+	p.unlink(e)
+	it.bucket = next
+	p.items[key] = next.Value.(*bucket).items.PushFront(it)
+}
+
+// Remove 删除 key 的访问记录，返回 key 是否存在
+func (p *LFU[K]) Remove(key K) bool {
+	e, ok := p.items[key]
+	if ok {
+		p.unlink(e)
+		delete(p.items, key)
+	}
+	return ok
+}
+
+// Victim 返回访问次数最少、其中最久没有访问的 key，不删除它
+func (p *LFU[K]) Victim() (K, bool) {
+	if front := p.buckets.Front(); front != nil {
+		return front.Value.(*bucket).items.Back().Value.(*item[K]).key, true
+	}
+	var zero K
+	return zero, false
+}
+
+// VictimExcept 与 Victim 相同，但跳过 skip。第一个桶中只有 skip 时取第二个桶的，
+// skip 只有一个，所以最多查看两个桶
+func (p *LFU[K]) VictimExcept(skip K) (K, bool) {
+	for b, i := p.buckets.Front(), 0; b != nil && i < 2; b, i = b.Next(), i+1 {
+		for e := b.Value.(*bucket).items.Back(); e != nil; e = e.Prev() {
+			if k := e.Value.(*item[K]).key; k != skip {
+				return k, true
+			}
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+// Len 返回记录的 key 数量
+func (p *LFU[K]) Len() int {
+	return len(p.items)
+}
+
+// unlink 把节点从所在的桶中移除，桶空了就删除
+func (p *LFU[K]) unlink(e *list.Element) {
+	b := e.Value.(*item[K]).bucket
+	bk := b.Value.(*bucket)
+	bk.items.Remove(e)
+	if bk.items.Len() == 0 {
+		p.buckets.Remove(b)
+	}
+}
@@ -0,0 +1,47 @@
+package lfu
+
+import "testing"
+
+// TestLFUVictim 先比较访问次数，次数相同时比较最近访问时间
+func TestLFUVictim(t *testing.T) {
+	p := New[string]()
+	if _, ok := p.Victim(); ok {
+		t.Fatalf("Victim() on empty LFU reported a key")
+	}
+	p.Add("a")
+	p.Add("b")
+	p.Add("c")
+	p.Access("a")
+	p.Access("a")
+	p.Access("b")
+	if k, _ := p.Victim(); k != "c" {
+		t.Fatalf("Victim() = %q, want c", k)
+	}
+	if k, _ := p.VictimExcept("c"); k != "b" {
+		t.Fatalf("VictimExcept(c) = %q, want b", k)
+	}
+	p.Access("c")
+	if k, _ := p.Victim(); k != "b" { // b 和 c 都访问过两次，b 更久没有访问
+		t.Fatalf("Victim() = %q, want b", k)
+	}
+	if !p.Remove("b") || p.Remove("b") || p.Len() != 2 {
+		t.Fatalf("Remove(b) twice left Len() = %d", p.Len())
+	}
+	if k, _ := p.Victim(); k != "c" {
+		t.Fatalf("Victim() = %q, want c", k)
+	}
+}
+
+// TestLFUVictimExceptOnly 只剩 skip 时没有可淘汰的 key
+func TestLFUVictimExceptOnly(t *testing.T) {
+	p := New[int]()
+	p.Add(1)
+	if _, ok := p.VictimExcept(1); ok {
+		t.Fatalf("VictimExcept returned the skipped key's neighbour in a single-key LFU")
+	}
+	p.Add(2)
+	p.Access(2)
+	if k, ok := p.VictimExcept(1); !ok || k != 2 {
+		t.Fatalf("VictimExcept(1) = %d, %v, want 2", k, ok)
+	}
+}
@@ -40,6 +40,8 @@ type SlidingLogLimiter struct {
 	smallWindow int64                        // 小窗口时间大小（纳秒）
 	counters    map[int64]int                // 每个小窗口的请求计数
 	mutex       sync.Mutex                   // 互斥锁，避免并发问题
+
+	now func() time.Time // 时间来源，默认 time.Now，测试中可替换为虚拟时钟
 }
 
 // NewSlidingLogLimiter 创建并初始化一个新的滑动日志限流器。
@@ -76,6 +78,7 @@ func NewSlidingLogLimiter(smallWindow time.Duration, strategies ...*SlidingLogLi
 		strategies:  strategiesCopy,
 		smallWindow: int64(smallWindow),
 		counters:    make(map[int64]int),
+		now:         time.Now,
 	}, nil
 }
 
@@ -84,7 +87,7 @@ func (l *SlidingLogLimiter) TryAcquire() error {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	now := time.Now().UnixNano()
+	now := l.now().UnixNano()
 	currentSmallWindow := now / l.smallWindow * l.smallWindow // 当前小窗口的起始点
 
 	// 计算每个策略的起始小窗口值
@@ -106,9 +109,9 @@ func (l *SlidingLogLimiter) TryAcquire() error {
 		}
 	}
 
-	// 检查是否违背了策略
+	// 检查是否违背了策略，加上本次请求后不能超过上限
 	for i, strategy := range l.strategies {
-		if counts[i] > strategy.limit {
+		if counts[i] >= strategy.limit {
 			return &ViolationStrategyError{
 				Limit:  strategy.limit,
 				Window: time.Duration(strategy.window),
@@ -0,0 +1,254 @@
+package limiter
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock 虚拟时钟，测试通过 Advance 手动推进时间，避免依赖真实时间导致结果不稳定
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	// 起始时间对齐到整秒，方便按小窗口划分
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// acquirer 是所有限流器的统一抽象，SlidingLogLimiter 通过 acquireFunc 适配
+type acquirer interface {
+	TryAcquire() bool
+}
+
+type acquireFunc func() bool
+
+func (f acquireFunc) TryAcquire() bool { return f() }
+
+// invariant 检查一次随机到达序列中被放行请求的时间点（按时间非递减）是否满足算法约束
+type invariant func(accepted []time.Time) error
+
+// conformanceCase 描述一个接入一致性测试的限流器
+type conformanceCase struct {
+	name       string
+	build      func(clock *fakeClock) acquirer // 使用虚拟时钟构造限流器
+	warmup     time.Duration                   // 并发测试前推进的时间
+	burst      int                             // 冻结时钟下并发请求应恰好放行的数量
+	invariants []invariant
+}
+
+// windowCap 任意长度为 span 的闭区间内放行的请求数不超过 limit
+func windowCap(limit int, span time.Duration) invariant {
+	return func(accepted []time.Time) error {
+		left := 0
+		for right, t := range accepted {
+			for accepted[left].Before(t.Add(-span)) {
+				left++
+			}
+			if n := right - left + 1; n > limit {
+				return fmt.Errorf("%d requests accepted in [%v, %v], limit %d within %v",
+					n, accepted[left], t, limit, span)
+			}
+		}
+		return nil
+	}
+}
+
+// burstCap 同一时刻放行的请求数不超过桶的容量
+func burstCap(capacity int) invariant {
+	return func(accepted []time.Time) error {
+		for i := 0; i < len(accepted); {
+			j := i
+			for j < len(accepted) && accepted[j].Equal(accepted[i]) {
+				j++
+			}
+			if j-i > capacity {
+				return fmt.Errorf("%d requests accepted at %v, capacity %d", j-i, accepted[i], capacity)
+			}
+			i = j
+		}
+		return nil
+	}
+}
+
+// bucketEnvelope 任意区间 [a, b] 内放行的请求数不超过 capacity + rate*(floor((b-a)/1s)+1)，
+// 即桶内存量加上区间内最多能补充（或漏掉）的数量
+func bucketEnvelope(capacity, rate int) invariant {
+	return func(accepted []time.Time) error {
+		for i := range accepted {
+			for j := i; j < len(accepted); j++ {
+				seconds := int(accepted[j].Sub(accepted[i]) / time.Second)
+				if n, max := j-i+1, capacity+rate*(seconds+1); n > max {
+					return fmt.Errorf("%d requests accepted in [%v, %v], envelope allows %d",
+						n, accepted[i], accepted[j], max)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+func conformanceCases() []conformanceCase {
+	return []conformanceCase{
+		{
+			name: "FixedWindow",
+			build: func(clock *fakeClock) acquirer {
+				l := NewFixedWindowLimiter(10, time.Second)
+				l.now, l.lastTime = clock.Now, clock.Now()
+				return l
+			},
+			burst: 10,
+			// 固定窗口在两个窗口交界处最多放行两倍的请求
+			invariants: []invariant{windowCap(20, time.Second)},
+		},
+		{
+			name: "SlidingWindow",
+			build: func(clock *fakeClock) acquirer {
+				l, err := NewSlidingWindowLimiter(10, time.Second, 100*time.Millisecond)
+				if err != nil {
+					panic(err)
+				}
+				l.now = clock.Now
+				return l
+			},
+			burst: 10,
+			// 窗口按小窗口对齐，任意跨度为 window-smallWindow 的区间都落在同一个窗口内
+			invariants: []invariant{windowCap(10, 900*time.Millisecond)},
+		},
+		{
+			name: "SlidingLog",
+			build: func(clock *fakeClock) acquirer {
+				l, err := NewSlidingLogLimiter(100*time.Millisecond,
+					NewSlidingLogLimiterStrategy(10, time.Second),
+					NewSlidingLogLimiterStrategy(5, 300*time.Millisecond))
+				if err != nil {
+					panic(err)
+				}
+				l.now = clock.Now
+				return acquireFunc(func() bool { return l.TryAcquire() == nil })
+			},
+			burst: 5,
+			invariants: []invariant{
+				windowCap(10, 900*time.Millisecond),
+				windowCap(5, 200*time.Millisecond),
+			},
+		},
+		{
+			name: "TokenBucket",
+			build: func(clock *fakeClock) acquirer {
+				l := NewTokenBucketLimiter(20, 5)
+				l.now, l.lastTime = clock.Now, clock.Now()
+				return l
+			},
+			// 初始没有令牌，推进一秒后发放 rate 个
+			warmup:     time.Second,
+			burst:      5,
+			invariants: []invariant{burstCap(20), bucketEnvelope(20, 5)},
+		},
+		{
+			name: "LeakyBucket",
+			build: func(clock *fakeClock) acquirer {
+				l, err := NewLeakyBucketLimiter(20, 5)
+				if err != nil {
+					panic(err)
+				}
+				l.now, l.lastTime = clock.Now, clock.Now()
+				return l
+			},
+			burst:      20,
+			invariants: []invariant{burstCap(20), bucketEnvelope(20, 5)},
+		},
+	}
+}
+
+// randomArrivals 按随机到达模式驱动限流器，返回被放行请求的时间点
+func randomArrivals(r *rand.Rand, clock *fakeClock, l acquirer, steps int) []time.Time {
+	var accepted []time.Time
+	for i := 0; i < steps; i++ {
+		// 混合同一时刻的突发、小于小窗口的间隔、跨小窗口的间隔以及跨整个窗口的空闲
+		switch p := r.Intn(10); {
+		case p < 3:
+		case p < 6:
+			clock.Advance(time.Duration(1+r.Intn(50)) * time.Millisecond)
+		case p < 9:
+			clock.Advance(time.Duration(50+r.Intn(400)) * time.Millisecond)
+		default:
+			clock.Advance(time.Duration(500+r.Intn(1500)) * time.Millisecond)
+		}
+		for n := 1 + r.Intn(5); n > 0; n-- {
+			if l.TryAcquire() {
+				accepted = append(accepted, clock.Now())
+			}
+		}
+	}
+	return accepted
+}
+
+// TestLimiterConformance 用随机到达序列检查每种限流算法的约束
+func TestLimiterConformance(t *testing.T) {
+	for _, c := range conformanceCases() {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			for seed := int64(1); seed <= 50; seed++ {
+				clock := newFakeClock()
+				l := c.build(clock)
+				accepted := randomArrivals(rand.New(rand.NewSource(seed)), clock, l, 300)
+				if len(accepted) == 0 {
+					t.Fatalf("seed %d: no request accepted", seed)
+				}
+				for _, check := range c.invariants {
+					if err := check(accepted); err != nil {
+						t.Fatalf("seed %d: %v", seed, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestLimiterConcurrentExactness 冻结时钟后并发请求，放行数量必须恰好等于可用额度，不能丢失更新
+func TestLimiterConcurrentExactness(t *testing.T) {
+	for _, c := range conformanceCases() {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			clock := newFakeClock()
+			l := c.build(clock)
+			clock.Advance(c.warmup)
+
+			var accepted int64
+			var wg sync.WaitGroup
+			for g := 0; g < 16; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 50; i++ {
+						if l.TryAcquire() {
+							atomic.AddInt64(&accepted, 1)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			if int(accepted) != c.burst {
+				t.Fatalf("accepted %d requests, want %d", accepted, c.burst)
+			}
+		})
+	}
+}
@@ -13,6 +13,8 @@ type FixedWindowLimiter struct {
 	counter  int           // 计数器，记录当前窗口内的请求数
 	lastTime time.Time     // 上一次请求的时间
 	mutex    sync.Mutex    // 互斥锁，用于同步，避免并发访问导致的问题
+
+	now func() time.Time // 时间来源，默认 time.Now，测试中可替换为虚拟时钟
 }
 
 // NewFixedWindowLimiter 构造函数创建并初始化一个新的 FixedWindowLimiter 实例。
@@ -21,6 +23,7 @@ func NewFixedWindowLimiter(limit int, window time.Duration) *FixedWindowLimiter
 		limit:    limit,
 		window:   window,
 		lastTime: time.Now(), // 初始化时设置当前时间为窗口开始时间
+		now:      time.Now,
 	}
 }
 
@@ -31,7 +34,7 @@ func (l *FixedWindowLimiter) TryAcquire() bool {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	now := time.Now()
+	now := l.now()
 	// 检查当前时间与上次请求时间差是否超过窗口大小
 	if now.Sub(l.lastTime) > l.window {
 		l.counter = 0    // 如果窗口过期，重置计数器
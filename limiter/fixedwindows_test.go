@@ -2,6 +2,7 @@ package limiter
 
 import (
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -9,11 +10,12 @@ import (
 func TestFixedWindowLimiter(t *testing.T) {
 	limiter := NewFixedWindowLimiter(100, time.Second) // 假设我们设置每秒限流100次请求
 
-	// 模拟并发请求的函数
+	// 模拟并发请求的函数，记录成功获取的次数
+	var acquired int64
 	testRequest := func(wg *sync.WaitGroup, limiter *FixedWindowLimiter) {
 		defer wg.Done()
-		if !limiter.TryAcquire() {
-			t.Errorf("Failed to acquire request limit")
+		if limiter.TryAcquire() {
+			atomic.AddInt64(&acquired, 1)
 		}
 	}
 
@@ -26,6 +28,11 @@ func TestFixedWindowLimiter(t *testing.T) {
 
 	// 等待所有并发请求完成
 	wg.Wait()
+
+	// 同一个窗口内只能放行 limit 个请求
+	if acquired != 100 {
+		t.Errorf("acquired %d requests, want 100", acquired)
+	}
 }
 
 func BenchmarkFixedWindowLimiter(b *testing.B) {
@@ -14,6 +14,8 @@ type LeakyBucketLimiter struct {
 	currentVelocity int          // 水流速度/秒
 	lastTime        time.Time    // 上次放水时间
 	mutex           sync.RWMutex // 使用读写锁提高并发性能
+
+	now func() time.Time // 时间来源，默认 time.Now，测试中可替换为虚拟时钟
 }
 
 // NewLeakyBucketLimiter 初始化漏桶限流器
@@ -29,24 +31,25 @@ func NewLeakyBucketLimiter(peakLevel, currentVelocity int) (*LeakyBucketLimiter,
 		currentLevel:    0, // 初始化时水位为0
 		currentVelocity: currentVelocity,
 		lastTime:        time.Now(),
+		now:             time.Now,
 	}, nil
 }
 
 // TryAcquire 尝试获取处理请求的权限
 func (l *LeakyBucketLimiter) TryAcquire() bool {
-	l.mutex.RLock() // 读锁，允许多个并发读取
-	defer l.mutex.RUnlock()
+	// 放水和加水都会修改水位，整个过程持有写锁。
+	// 注意不能先持有读锁再申请写锁，RWMutex 不支持锁升级，会直接死锁。
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 
 	// 如果上次放水时间距今不到1秒，不需要放水
-	now := time.Now()
+	now := l.now()
 	interval := now.Sub(l.lastTime)
 
-	l.mutex.Lock() // 写锁，更新水位
-	defer l.mutex.Unlock()
-
-	// 计算放水后的水位
+	// 计算放水后的水位，按经过的整秒数放水
 	if interval >= time.Second {
-		l.currentLevel = int(math.Max(0, float64(l.currentLevel)-(interval/time.Second).Seconds()*float64(l.currentVelocity)))
+		leaked := float64(interval/time.Second) * float64(l.currentVelocity)
+		l.currentLevel = int(math.Max(0, float64(l.currentLevel)-leaked))
 		l.lastTime = now
 	}
 	// 尝试增加水位
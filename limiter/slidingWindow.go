@@ -14,6 +14,8 @@ type SlidingWindowLimiter struct {
 	smallWindows int64         // 窗口内小窗口的数量
 	counters     map[int64]int // 每个小窗口的请求计数
 	mutex        sync.RWMutex  // 使用读写锁提高并发性能
+
+	now func() time.Time // 时间来源，默认 time.Now，测试中可替换为虚拟时钟
 }
 
 // NewSlidingWindowLimiter 创建并初始化滑动窗口限流器。
@@ -27,35 +29,39 @@ func NewSlidingWindowLimiter(limit int, window, smallWindow time.Duration) (*Sli
 		smallWindow:  int64(smallWindow),
 		smallWindows: int64(window / smallWindow),
 		counters:     make(map[int64]int),
+		now:          time.Now,
 	}, nil
 }
 
 // TryAcquire 尝试在当前窗口内获取一个请求的机会。
+// 窗口由当前小窗口以及之前的 smallWindows-1 个小窗口组成，窗口内的请求总数不超过 limit。
 func (l *SlidingWindowLimiter) TryAcquire() bool {
-	l.mutex.RLock() // 读锁，允许多个并发读取
-	defer l.mutex.RUnlock()
+	// 清理和计数都会修改 counters，整个过程持有写锁。
+	// 注意不能先持有读锁再申请写锁，RWMutex 不支持锁升级，会直接死锁。
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 
-	now := time.Now().UnixNano()
+	now := l.now().UnixNano()
 	currentSmallWindow := now / l.smallWindow * l.smallWindow // 当前小窗口的起始点
+	startSmallWindow := currentSmallWindow - l.smallWindow*(l.smallWindows-1)
 
 	// 清理过期的小窗口计数器
-	l.cleanExpiredWindows(now)
+	l.cleanExpiredWindows(startSmallWindow)
 
-	// 检查并更新当前小窗口的计数
-	l.mutex.Lock() // 写锁，更新计数器
-	defer l.mutex.Unlock()
-
-	count, exists := l.counters[currentSmallWindow]
-	if !exists || count < l.limit {
-		l.counters[currentSmallWindow] = count + 1
-		return true
+	// 统计窗口内所有小窗口的请求总数
+	count := 0
+	for _, counter := range l.counters {
+		count += counter
+	}
+	if count >= l.limit {
+		return false
 	}
-	return false
+	l.counters[currentSmallWindow]++
+	return true
 }
 
-// cleanExpiredWindows 清理已过期的小窗口计数器。
-func (l *SlidingWindowLimiter) cleanExpiredWindows(now int64) {
-	startSmallWindow := now/l.smallWindow*l.smallWindow - l.window
+// cleanExpiredWindows 清理起始点早于 startSmallWindow 的小窗口计数器。
+func (l *SlidingWindowLimiter) cleanExpiredWindows(startSmallWindow int64) {
 	for smallWindow := range l.counters {
 		if smallWindow < startSmallWindow {
 			delete(l.counters, smallWindow)
@@ -63,4 +69,4 @@ func (l *SlidingWindowLimiter) cleanExpiredWindows(now int64) {
 	}
 }
 
-// 注意：cleanExpiredWindows 方法应该在持有读锁的情况下调用，以避免在遍历和修改计数器时产生竞态条件。
+// 注意：cleanExpiredWindows 方法需要在持有写锁的情况下调用，以避免在遍历和修改计数器时产生竞态条件。
@@ -12,6 +12,8 @@ type TokenBucketLimiter struct {
 	rate          int        // 发放令牌速率/秒
 	lastTime      time.Time  // 上次发放令牌时间
 	mutex         sync.Mutex // 避免并发问题
+
+	now func() time.Time // 时间来源，默认 time.Now，测试中可替换为虚拟时钟
 }
 
 // NewTokenBucketLimiter 创建一个新的令牌桶限流器实例。
@@ -21,6 +23,7 @@ func NewTokenBucketLimiter(capacity, rate int) *TokenBucketLimiter {
 		rate:          rate,
 		lastTime:      time.Now(),
 		currentTokens: 0, // 初始化时桶中没有令牌
+		now:           time.Now,
 	}
 }
 
@@ -29,7 +32,7 @@ func (l *TokenBucketLimiter) TryAcquire() bool {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	now := time.Now()
+	now := l.now()
 	interval := now.Sub(l.lastTime) // 计算时间间隔
 
 	// 如果距离上次发放令牌超过1秒，则发放新的令牌